@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/go-errors/errors"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/common/util"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
@@ -23,7 +24,7 @@ func WriteServicesToFile(service model.Service, cacheDir string) {
 
 	err := ioutil.WriteFile(domFileName, sb, 0666)
 	if err != nil {
-		// log.Printf("[ERROR]:faild to write name cache:%s ,value:%s ,err:%s \n", domFileName, string(sb), err.Error())
+		logger.Error("faild to write name cache", logger.F("file", domFileName), logger.Err(err))
 	}
 
 }
@@ -31,7 +32,7 @@ func WriteServicesToFile(service model.Service, cacheDir string) {
 func ReadServicesFromFile(cacheDir string) map[string]model.Service {
 	files, err := ioutil.ReadDir(cacheDir)
 	if err != nil {
-		// log.Printf("[ERROR]:read cacheDir:%s failed!err:%s \n", cacheDir, err.Error())
+		logger.Error("read cacheDir failed", logger.F("cacheDir", cacheDir), logger.Err(err))
 		return nil
 	}
 	serviceMap := map[string]model.Service{}
@@ -39,7 +40,7 @@ func ReadServicesFromFile(cacheDir string) map[string]model.Service {
 		fileName := GetFileName(f.Name(), cacheDir)
 		b, err := ioutil.ReadFile(fileName)
 		if err != nil {
-			// log.Printf("[ERROR]:failed to read name cache file:%s,err:%s! ", fileName, err.Error())
+			logger.Error("failed to read name cache file", logger.F("file", fileName), logger.Err(err))
 			continue
 		}
 
@@ -53,7 +54,7 @@ func ReadServicesFromFile(cacheDir string) map[string]model.Service {
 		serviceMap[f.Name()] = *service
 	}
 
-	// log.Printf("finish loading name cache, total: " + strconv.Itoa(len(files)))
+	logger.Info("finish loading name cache", logger.F("total", len(files)))
 	return serviceMap
 }
 
@@ -62,7 +63,7 @@ func WriteConfigToFile(cacheKey string, cacheDir string, content string) {
 	fileName := GetFileName(cacheKey, cacheDir)
 	err := ioutil.WriteFile(fileName, []byte(content), 0666)
 	if err != nil {
-		// log.Printf("[ERROR]:faild to write config  cache:%s ,value:%s ,err:%s \n", fileName, string(content), err.Error())
+		logger.Error("faild to write config cache", logger.F("file", fileName), logger.Err(err))
 	}
 }
 
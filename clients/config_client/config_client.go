@@ -1,10 +1,12 @@
 package config_client
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,8 +16,11 @@ import (
 	"github.com/uugtv/nacos-sdk-go/clients/nacos_client"
 	"github.com/uugtv/nacos-sdk-go/common/constant"
 	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/common/nacos_error"
+	"github.com/uugtv/nacos-sdk-go/common/security"
 	"github.com/uugtv/nacos-sdk-go/common/util"
+	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
 	"github.com/uugtv/nacos-sdk-go/vo"
 
@@ -24,11 +29,16 @@ import (
 
 type ConfigClient struct {
 	nacos_client.INacosClient
-	kmsClient      *kms.Client
-	localConfigs   []vo.ConfigParam
-	mutex          sync.Mutex
-	configProxy    ConfigProxy
-	configCacheDir string
+	kmsClient       *kms.Client
+	ciphers         *security.Registry
+	localConfigs    []vo.ConfigParam
+	mutex           sync.Mutex
+	configProxy     ConfigProxy
+	configCacheDir  string
+	failoverDir     string
+	failovers       cache.ConcurrentMap
+	listenerManager *ListenerManager
+	configInfos     cache.ConcurrentMap
 }
 
 func NewConfigClient(nc nacos_client.INacosClient) (ConfigClient, error) {
@@ -46,18 +56,35 @@ func NewConfigClient(nc nacos_client.INacosClient) (ConfigClient, error) {
 	if err != nil {
 		return config, err
 	}
-	/*err = logger.InitLog(clientConfig.LogDir)
+	err = logger.InitLogWithLevel(clientConfig.LogDir, clientConfig.LogLevel)
 	if err != nil {
 		return config, err
-	}*/
+	}
 	config.configCacheDir = clientConfig.CacheDir + string(os.PathSeparator) + "config"
+	config.failoverDir = clientConfig.CacheDir + string(os.PathSeparator) + "config-failover"
+	config.failovers = cache.NewConcurrentMap()
 	config.configProxy, err = NewConfigProxy(serverConfig, clientConfig, httpAgent)
+	config.listenerManager = NewListenerManager(clientConfig.PerTaskConfigSize)
+	config.configInfos = cache.NewConcurrentMap()
+	config.ciphers = security.NewRegistry()
+	if clientConfig.OpenKMS && len(clientConfig.CipherKeyFile) > 0 {
+		// 两者都处理 cipher- 开头的 dataId，后注册的会覆盖先注册的
+		return config, errors.New("[client.NewConfigClient] OpenKMS and CipherKeyFile can not be set at the same time")
+	}
 	if clientConfig.OpenKMS {
 		kmsClient, err := kms.NewClientWithAccessKey(clientConfig.RegionId, clientConfig.AccessKey, clientConfig.SecretKey)
 		if err != nil {
 			return config, err
 		}
 		config.kmsClient = kmsClient
+		config.ciphers.Register(constant.CIPHER_DATA_ID_PREFIX, newKmsCipherProvider(kmsClient, clientConfig.KMSKeyId))
+	}
+	if len(clientConfig.CipherKeyFile) > 0 {
+		provider, err := security.NewAesGcmProviderFromFile(clientConfig.CipherKeyFile)
+		if err != nil {
+			return config, err
+		}
+		config.ciphers.Register(constant.CIPHER_DATA_ID_PREFIX, provider)
 	}
 
 	return config, err
@@ -67,25 +94,29 @@ func (client *ConfigClient) sync() (clientConfig constant.ClientConfig,
 	serverConfigs []constant.ServerConfig, agent http_agent.IHttpAgent, err error) {
 	clientConfig, err = client.GetClientConfig()
 	if err != nil {
-		// log.Println(err, ";do you call client.SetClientConfig()?")
+		logger.Error("[client.sync] do you call client.SetClientConfig()?", logger.Err(err))
 	}
 	if err == nil {
 		serverConfigs, err = client.GetServerConfig()
 		if err != nil {
-			// log.Println(err, ";do you call client.SetServerConfig()?")
+			logger.Error("[client.sync] do you call client.SetServerConfig()?", logger.Err(err))
 		}
 	}
 	if err == nil {
 		agent, err = client.GetHttpAgent()
 		if err != nil {
-			// log.Println(err, ";do you call client.SetHttpAgent()?")
+			logger.Error("[client.sync] do you call client.SetHttpAgent()?", logger.Err(err))
 		}
 	}
 	return
 }
 
 func (client *ConfigClient) GetConfig(param vo.ConfigParam) (content string, err error) {
-	content, err = client.getConfigInner(param)
+	return client.GetConfigWithContext(context.Background(), param)
+}
+
+func (client *ConfigClient) GetConfigWithContext(ctx context.Context, param vo.ConfigParam) (content string, err error) {
+	content, err = client.getConfigInner(ctx, param)
 
 	if err != nil {
 		return "", err
@@ -94,24 +125,55 @@ func (client *ConfigClient) GetConfig(param vo.ConfigParam) (content string, err
 	return client.decrypt(param.DataId, content)
 }
 
+// GetConfigInto 获取配置并解码到 target（结构体或 map 的指针），格式由 param.Format 指定或自动识别
+// 解码失败时返回错误，target 保持不变
+func (client *ConfigClient) GetConfigInto(param vo.ConfigParam, target interface{}) error {
+	content, err := client.GetConfig(param)
+	if err != nil {
+		return err
+	}
+	value, err := decodeConfigNew(param.DataId, content, param.Format, target)
+	if err != nil {
+		return err
+	}
+	reflect.ValueOf(target).Elem().Set(reflect.ValueOf(value).Elem())
+	return nil
+}
+
+// RegisterCipherProvider 为以 prefix 开头的 dataId 注册加解密实现，发布时加密，获取和监听时解密
+func (client *ConfigClient) RegisterCipherProvider(prefix string, provider security.CipherProvider) {
+	client.ciphers.Register(prefix, provider)
+}
+
 func (client *ConfigClient) decrypt(dataId, content string) (string, error) {
-	if strings.HasPrefix(dataId, "cipher-") && client.kmsClient != nil {
-		request := kms.CreateDecryptRequest()
-		request.Method = "POST"
-		request.Scheme = "https"
-		request.AcceptFormat = "json"
-		request.CiphertextBlob = content
-		response, err := client.kmsClient.Decrypt(request)
-		if err != nil {
-			return "", errors.New("ksm decrypt failed")
-		}
-		content = response.Plaintext
+	if client.ciphers == nil {
+		return content, nil
+	}
+	if provider, ok := client.ciphers.Lookup(dataId); ok {
+		return provider.Decrypt(dataId, content)
 	}
+	return content, nil
+}
 
+// encrypt 加密待发布的配置，cipher- 开头的 dataId 没有可用的加密实现时返回错误，避免明文发布
+func (client *ConfigClient) encrypt(dataId, content string) (string, error) {
+	if client.ciphers != nil {
+		if provider, ok := client.ciphers.Lookup(dataId); ok {
+			encrypted, err := provider.Encrypt(dataId, content)
+			if err != nil {
+				return "", errors.New("[client.PublishConfig] encrypt config failed:" + err.Error())
+			}
+			return encrypted, nil
+		}
+	}
+	if strings.HasPrefix(dataId, constant.CIPHER_DATA_ID_PREFIX) {
+		return "", errors.New("[client.PublishConfig] no cipher configured for dataId " + dataId +
+			", set ClientConfig.OpenKMS or ClientConfig.CipherKeyFile, or call RegisterCipherProvider")
+	}
 	return content, nil
 }
 
-func (client *ConfigClient) getConfigInner(param vo.ConfigParam) (content string, err error) {
+func (client *ConfigClient) getConfigInner(ctx context.Context, param vo.ConfigParam) (content string, err error) {
 	if len(param.DataId) <= 0 {
 		err = errors.New("[client.GetConfig] param.dataId can not be empty")
 	}
@@ -120,14 +182,23 @@ func (client *ConfigClient) getConfigInner(param vo.ConfigParam) (content string
 	}
 	clientConfig, _ := client.GetClientConfig()
 	cacheKey := utils.GetConfigCacheKey(param.DataId, param.Group, clientConfig.NamespaceId)
-	content, err = client.configProxy.GetConfigProxy(param, clientConfig.NamespaceId, clientConfig.AccessKey, clientConfig.SecretKey)
+	if failover, ok := client.readFailover(param.DataId, param.Group, clientConfig.NamespaceId); ok {
+		client.updateConfigInfo(cacheKey, param, clientConfig.NamespaceId, failover, "", model.CONFIG_SOURCE_FAILOVER)
+		return failover, nil
+	}
+	content, server, err := client.configProxy.GetConfigProxy(ctx, param, clientConfig.NamespaceId, clientConfig.AccessKey, clientConfig.SecretKey)
 
 	if err != nil {
-		// log.Printf("[ERROR] get config from server error:%s ", err.Error())
+		// 调用方取消时不再回退到本地缓存
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		logger.Error("[client.GetConfig] get config from server error", logger.F("dataId", param.DataId), logger.F("group", param.Group), logger.Err(err))
 		if _, ok := err.(*nacos_error.NacosError); ok {
 			nacosErr := err.(*nacos_error.NacosError)
 			if nacosErr.ErrorCode() == "404" {
 				cache.WriteConfigToFile(cacheKey, client.configCacheDir, "")
+				client.configInfos.Remove(cacheKey)
 				return "", errors.New("config not found")
 			}
 			if nacosErr.ErrorCode() == "403" {
@@ -136,32 +207,105 @@ func (client *ConfigClient) getConfigInner(param vo.ConfigParam) (content string
 		}
 		content, err = cache.ReadConfigFromFile(cacheKey, client.configCacheDir)
 		if err != nil {
-			// log.Printf("[ERROR] get config from cache  error:%s ", err.Error())
+			logger.Error("[client.GetConfig] get config from cache error", logger.F("dataId", param.DataId), logger.F("group", param.Group), logger.Err(err))
 			return "", errors.New("read config from both server and cache fail")
 		}
-
+		client.updateConfigInfo(cacheKey, param, clientConfig.NamespaceId, content, "", model.CONFIG_SOURCE_CACHE)
 	} else {
 		cache.WriteConfigToFile(cacheKey, client.configCacheDir, content)
+		client.updateConfigInfo(cacheKey, param, clientConfig.NamespaceId, content, server, model.CONFIG_SOURCE_SERVER)
 	}
 	return content, nil
 }
 
+// 故障转移目录下存在该配置的文件时，优先使用文件内容；文件被删除后恢复从服务端获取
+func (client *ConfigClient) readFailover(dataId, group, tenant string) (string, bool) {
+	cacheKey := utils.GetConfigCacheKey(dataId, group, tenant)
+	content, err := cache.ReadConfigFromFile(cacheKey, client.failoverDir)
+	active := err == nil
+	if _, wasActive := client.failovers.Get(cacheKey); active != wasActive {
+		if active {
+			client.failovers.Set(cacheKey, true)
+			logger.Warn("[client.failover] failover is active", logger.F("dataId", dataId), logger.F("group", group),
+				logger.F("tenant", tenant), logger.F("file", cache.GetFileName(cacheKey, client.failoverDir)))
+		} else {
+			client.failovers.Remove(cacheKey)
+			logger.Info("[client.failover] failover is removed, switch back to server", logger.F("dataId", dataId),
+				logger.F("group", group), logger.F("tenant", tenant))
+		}
+	}
+	return content, active
+}
+
+// 内容的 md5 不变时保留原来的修改时间
+func (client *ConfigClient) updateConfigInfo(cacheKey string, param vo.ConfigParam, tenant string, content string,
+	server string, source model.ConfigSource) {
+	md5 := util.Md5(content)
+	client.configInfos.Upsert(cacheKey, nil, func(exist bool, valueInMap interface{}, newValue interface{}) interface{} {
+		info := model.ConfigInfo{
+			DataId:       param.DataId,
+			Group:        param.Group,
+			Tenant:       tenant,
+			Md5:          md5,
+			LastModified: time.Now(),
+			Server:       server,
+			Source:       source,
+		}
+		if exist {
+			if old := valueInMap.(model.ConfigInfo); old.Md5 == md5 {
+				info.LastModified = old.LastModified
+			}
+		}
+		return info
+	})
+}
+
+// 获取客户端当前持有的配置元信息（md5、修改时间、来源），用于确认进程实际使用的配置版本
+func (client *ConfigClient) GetConfigInfo(param vo.ConfigParam) (model.ConfigInfo, error) {
+	if len(param.DataId) <= 0 {
+		return model.ConfigInfo{}, errors.New("[client.GetConfigInfo] param.dataId can not be empty")
+	}
+	if len(param.Group) <= 0 {
+		return model.ConfigInfo{}, errors.New("[client.GetConfigInfo] param.group can not be empty")
+	}
+	clientConfig, _ := client.GetClientConfig()
+	info, ok := client.configInfos.Get(utils.GetConfigCacheKey(param.DataId, param.Group, clientConfig.NamespaceId))
+	if !ok {
+		return model.ConfigInfo{}, errors.New("[client.GetConfigInfo] config has not been fetched")
+	}
+	return info.(model.ConfigInfo), nil
+}
+
 func (client *ConfigClient) PublishConfig(param vo.ConfigParam) (published bool,
+	err error) {
+	return client.PublishConfigWithContext(context.Background(), param)
+}
+
+func (client *ConfigClient) PublishConfigWithContext(ctx context.Context, param vo.ConfigParam) (published bool,
 	err error) {
 	if len(param.DataId) <= 0 {
-		err = errors.New("[client.PublishConfig] param.dataId can not be empty")
+		return false, errors.New("[client.PublishConfig] param.dataId can not be empty")
 	}
 	if len(param.Group) <= 0 {
-		err = errors.New("[client.PublishConfig] param.group can not be empty")
+		return false, errors.New("[client.PublishConfig] param.group can not be empty")
 	}
 	if len(param.Content) <= 0 {
-		err = errors.New("[client.PublishConfig] param.content can not be empty")
+		return false, errors.New("[client.PublishConfig] param.content can not be empty")
+	}
+	param.Content, err = client.encrypt(param.DataId, param.Content)
+	if err != nil {
+		return false, err
 	}
 	clientConfig, _ := client.GetClientConfig()
-	return client.configProxy.PublishConfigProxy(param, clientConfig.NamespaceId, clientConfig.AccessKey, clientConfig.SecretKey)
+	return client.configProxy.PublishConfigProxy(ctx, param, clientConfig.NamespaceId, clientConfig.AccessKey, clientConfig.SecretKey)
 }
 
 func (client *ConfigClient) DeleteConfig(param vo.ConfigParam) (deleted bool,
+	err error) {
+	return client.DeleteConfigWithContext(context.Background(), param)
+}
+
+func (client *ConfigClient) DeleteConfigWithContext(ctx context.Context, param vo.ConfigParam) (deleted bool,
 	err error) {
 	if len(param.DataId) <= 0 {
 		err = errors.New("[client.DeleteConfig] param.dataId can not be empty")
@@ -171,7 +315,7 @@ func (client *ConfigClient) DeleteConfig(param vo.ConfigParam) (deleted bool,
 	}
 
 	clientConfig, _ := client.GetClientConfig()
-	return client.configProxy.DeleteConfigProxy(param, clientConfig.NamespaceId, clientConfig.AccessKey, clientConfig.SecretKey)
+	return client.configProxy.DeleteConfigProxy(ctx, param, clientConfig.NamespaceId, clientConfig.AccessKey, clientConfig.SecretKey)
 }
 
 func (client *ConfigClient) AddConfigToListen(params []vo.ConfigParam) (err error) {
@@ -197,68 +341,167 @@ func (client *ConfigClient) AddConfigToListen(params []vo.ConfigParam) (err erro
 }
 
 func (client *ConfigClient) ListenConfig(param vo.ConfigParam) (err error) {
-	go func() {
-		for {
-			clientConfig, serverConfigs, agent, err := client.sync()
-			// 创建计时器
-			var timer *time.Timer
-			if err == nil {
-				timer = time.NewTimer(time.Duration(clientConfig.ListenInterval) * time.Millisecond)
-			}
-			client.listenConfigTask(clientConfig, serverConfigs, agent, param)
-			<-timer.C
+	if len(param.DataId) <= 0 {
+		return errors.New("[client.ListenConfig] DataId can not be empty")
+	}
+	if len(param.Group) <= 0 {
+		return errors.New("[client.ListenConfig] Group can not be empty")
+	}
+	if client.listenerManager.IsClosed() {
+		return errors.New("[client.ListenConfig] client is closed")
+	}
+	if param.OnTypedChange != nil {
+		if param.Target == nil || reflect.TypeOf(param.Target).Kind() != reflect.Ptr {
+			return errors.New("[client.ListenConfig] Target must be a pointer when OnTypedChange is set")
 		}
-	}()
-
+		param.OnChange = typedOnChange(param)
+	}
+	clientConfig, _ := client.GetClientConfig()
+	taskId, start := client.listenerManager.AddListener(clientConfig.NamespaceId, param)
+	if start {
+		go client.runListenTask(taskId)
+	}
 	return nil
 }
 
-func (client *ConfigClient) listenConfigTask(clientConfig constant.ClientConfig,
-	serverConfigs []constant.ServerConfig, agent http_agent.IHttpAgent, param vo.ConfigParam) {
-	var listeningConfigs string
-	// 检查&拼接监听参数
-	client.mutex.Lock()
+// 将原始内容解码后回调 OnTypedChange，内容无法解码时只回调错误，不会覆盖之前的值
+func typedOnChange(param vo.ConfigParam) func(namespace, group, dataId, data string) {
+	onChange := param.OnChange
+	return func(namespace, group, dataId, data string) {
+		if onChange != nil {
+			onChange(namespace, group, dataId, data)
+		}
+		value, err := decodeConfigNew(dataId, data, param.Format, param.Target)
+		if err != nil {
+			logger.Error("[client.ListenConfig] decode config failed", logger.F("dataId", dataId), logger.F("group", group), logger.Err(err))
+			param.OnTypedChange(namespace, group, dataId, nil, err)
+			return
+		}
+		param.OnTypedChange(namespace, group, dataId, value, nil)
+	}
+}
+
+// 同一个任务内的所有配置共用一个长轮询连接
+func (client *ConfigClient) runListenTask(taskId int) {
+	for {
+		select {
+		case <-client.listenerManager.Done():
+			return
+		default:
+		}
+		clientConfig, serverConfigs, agent, err := client.sync()
+		if err != nil {
+			time.Sleep(time.Second)
+			continue
+		}
+		params := client.listenerManager.TaskParams(taskId)
+		if len(params) == 0 {
+			// 任务内的监听已全部取消
+			return
+		}
+		// 创建计时器
+		timer := time.NewTimer(time.Duration(clientConfig.ListenInterval) * time.Millisecond)
+		client.listenConfigsTask(clientConfig, serverConfigs, agent, params)
+		select {
+		case <-client.listenerManager.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (client *ConfigClient) CancelListenConfig(param vo.ConfigParam) (err error) {
 	if len(param.DataId) <= 0 {
-		// log.Fatalf("[client.ListenConfig] DataId can not be empty")
-		return
+		return errors.New("[client.CancelListenConfig] DataId can not be empty")
 	}
 	if len(param.Group) <= 0 {
-		// log.Fatalf("[client.ListenConfig] Group can not be empty")
-		return
+		return errors.New("[client.CancelListenConfig] Group can not be empty")
+	}
+	clientConfig, _ := client.GetClientConfig()
+	if !client.listenerManager.RemoveListener(clientConfig.NamespaceId, param) {
+		return errors.New("[client.CancelListenConfig] config is not listening")
 	}
+	client.forgetConfig(clientConfig.NamespaceId, param)
+	return nil
+}
+
+func (client *ConfigClient) listenConfigTask(clientConfig constant.ClientConfig,
+	serverConfigs []constant.ServerConfig, agent http_agent.IHttpAgent, param vo.ConfigParam) {
+	client.listenConfigsTask(clientConfig, serverConfigs, agent, []vo.ConfigParam{param})
+}
+
+func (client *ConfigClient) listenConfigsTask(clientConfig constant.ClientConfig,
+	serverConfigs []constant.ServerConfig, agent http_agent.IHttpAgent, params []vo.ConfigParam) {
+	var listeningConfigs string
 	var tenant string
 	if len(clientConfig.NamespaceId) > 0 {
 		tenant = clientConfig.NamespaceId
 	}
+	// 检查&拼接监听参数
+	var failoverChanged []vo.ConfigParam
+	client.mutex.Lock()
+	appended := map[string]bool{}
+	for _, param := range params {
+		if len(param.DataId) <= 0 || len(param.Group) <= 0 {
+			continue
+		}
+		key := listenKey(param.DataId, param.Group, tenant)
+		if appended[key] {
+			continue
+		}
+		appended[key] = true
 
-	for _, config := range client.localConfigs {
-		if config.Group == param.Group && config.DataId == param.DataId {
-			param.Content = config.Content
-			break
+		for _, config := range client.localConfigs {
+			if config.Group == param.Group && config.DataId == param.DataId {
+				param.Content = config.Content
+				break
+			}
+		}
+
+		// 故障转移生效时不向服务端监听，直接以文件内容为准；
+		// 文件删除后本地内容与服务端不一致，下一次监听会拿到服务端的内容
+		if failover, ok := client.readFailover(param.DataId, param.Group, tenant); ok {
+			if failover != param.Content {
+				client.putLocalConfig(vo.ConfigParam{DataId: param.DataId, Group: param.Group, Content: failover})
+				failoverChanged = append(failoverChanged, vo.ConfigParam{DataId: param.DataId, Group: param.Group, Content: failover})
+			}
+			continue
 		}
-	}
 
-	var md5 string
-	if len(param.Content) > 0 {
-		md5 = util.Md5(param.Content)
+		var md5 string
+		if len(param.Content) > 0 {
+			md5 = util.Md5(param.Content)
+		}
+		if len(tenant) > 0 {
+			listeningConfigs += param.DataId + constant.SPLIT_CONFIG_INNER + param.Group + constant.SPLIT_CONFIG_INNER +
+				md5 + constant.SPLIT_CONFIG_INNER + tenant + constant.SPLIT_CONFIG
+		} else {
+			listeningConfigs += param.DataId + constant.SPLIT_CONFIG_INNER + param.Group + constant.SPLIT_CONFIG_INNER +
+				md5 + constant.SPLIT_CONFIG
+		}
 	}
-	if len(tenant) > 0 {
-		listeningConfigs += param.DataId + constant.SPLIT_CONFIG_INNER + param.Group + constant.SPLIT_CONFIG_INNER +
-			md5 + constant.SPLIT_CONFIG_INNER + tenant + constant.SPLIT_CONFIG
-	} else {
-		listeningConfigs += param.DataId + constant.SPLIT_CONFIG_INNER + param.Group + constant.SPLIT_CONFIG_INNER +
-			md5 + constant.SPLIT_CONFIG
+	client.mutex.Unlock()
+	for _, changed := range failoverChanged {
+		client.notifyListeners(tenant, changed.DataId, changed.Group, changed.Content, params)
+	}
+	if len(listeningConfigs) == 0 {
+		return
 	}
 
-	client.mutex.Unlock()
 	// http 请求
-	params := make(map[string]string)
-	params[constant.KEY_LISTEN_CONFIGS] = listeningConfigs
+	httpParams := make(map[string]string)
+	httpParams[constant.KEY_LISTEN_CONFIGS] = listeningConfigs
 	var changed string
 
+	ctx := client.listenerManager.Context()
 	for _, serverConfig := range client.configProxy.GetServerList() {
 		path := client.buildBasePath(serverConfig) + "/listener"
-		changedTmp, err := listen(agent, path, clientConfig.TimeoutMs, clientConfig.ListenInterval, params)
+		changedTmp, err := listen(ctx, agent, path, clientConfig.TimeoutMs, clientConfig.ListenInterval, httpParams)
+		if ctx.Err() != nil {
+			// 客户端已关闭
+			return
+		}
 		if err == nil {
 			changed = changedTmp
 			break
@@ -267,29 +510,29 @@ func (client *ConfigClient) listenConfigTask(clientConfig constant.ClientConfig,
 				changed = changedTmp
 				break
 			} else {
-				// log.Println("[client.ListenConfig] listen config error:", err.Error())
+				logger.Error("[client.ListenConfig] listen config error", logger.Err(err))
 			}
 		}
 	}
 
 	if strings.ToLower(strings.Trim(changed, " ")) == "" {
-		// log.Println("[client.ListenConfig] no change")
+		logger.Debug("[client.ListenConfig] no change")
 	} else {
-		// log.Print("[client.ListenConfig] config changed:" + changed)
-		client.updateLocalConfig(changed, param)
+		logger.Info("[client.ListenConfig] config changed", logger.F("changed", changed))
+		client.updateLocalConfig(ctx, changed, params)
 	}
 }
 
-func listen(agent http_agent.IHttpAgent, path string,
+func listen(ctx context.Context, agent http_agent.IHttpAgent, path string,
 	timeoutMs uint64, listenInterval uint64,
 	params map[string]string) (changed string, err error) {
 	header := map[string][]string{
 		"Content-Type":         {"application/x-www-form-urlencoded"},
 		"Long-Pulling-Timeout": {strconv.FormatUint(listenInterval, 10)},
 	}
-	// log.Println("[client.ListenConfig] request url:", path, " ;params:", params, " ;header:", header)
+	logger.Debug("[client.ListenConfig] request", logger.F("url", path), logger.F("params", params))
 	var response *http.Response
-	response, err = agent.Post(path, header, timeoutMs, params)
+	response, err = agent.RequestWithContext(ctx, http.MethodPost, path, header, timeoutMs, params)
 	if err == nil {
 		bytes, errRead := ioutil.ReadAll(response.Body)
 		defer response.Body.Close()
@@ -306,53 +549,59 @@ func listen(agent http_agent.IHttpAgent, path string,
 	return
 }
 
-func (client *ConfigClient) updateLocalConfig(changed string, param vo.ConfigParam) {
-	client.mutex.Lock()
-	defer client.mutex.Unlock()
+func (client *ConfigClient) updateLocalConfig(ctx context.Context, changed string, params []vo.ConfigParam) {
+	clientConfig, _ := client.GetClientConfig()
+	namespaceId := clientConfig.NamespaceId
 	changedConfigs := strings.Split(changed, "%01")
 	for _, config := range changedConfigs {
 		attrs := strings.Split(config, "%02")
-		if len(attrs) == 2 {
-			content, err := client.getConfigInner(vo.ConfigParam{
-				DataId: attrs[0],
-				Group:  attrs[1],
-			})
-			if err != nil {
-				// log.Println("[client.updateLocalConfig] update config failed:", err.Error())
-			} else {
-				client.putLocalConfig(vo.ConfigParam{
-					DataId:  attrs[0],
-					Group:   attrs[1],
-					Content: content,
-				})
-
-				// call listener:
-				decrept, _ := client.decrypt(attrs[0], content)
-				param.OnChange("", attrs[1], attrs[0], decrept)
+		if len(attrs) != 2 && len(attrs) != 3 {
+			continue
+		}
+		var tenant string
+		if len(attrs) == 3 {
+			tenant = attrs[2]
+		}
+		content, err := client.getConfigInner(ctx, vo.ConfigParam{
+			DataId: attrs[0],
+			Group:  attrs[1],
+		})
+		if err != nil {
+			logger.Error("[client.updateLocalConfig] update config failed", logger.F("dataId", attrs[0]), logger.F("group", attrs[1]), logger.Err(err))
+			continue
+		}
+		// 查询期间最后一个监听可能已被取消或客户端已关闭，此时清除查询写入的缓存，不再放回 localConfigs
+		client.mutex.Lock()
+		if client.listenerManager.IsClosed() || !client.listenerManager.HasListener(namespaceId, attrs[0], attrs[1]) {
+			client.forgetConfigLocked(namespaceId, vo.ConfigParam{DataId: attrs[0], Group: attrs[1]})
+			client.mutex.Unlock()
+			continue
+		}
+		client.putLocalConfig(vo.ConfigParam{
+			DataId:  attrs[0],
+			Group:   attrs[1],
+			Content: content,
+		})
+		client.mutex.Unlock()
+
+		// call listener:
+		client.notifyListeners(tenant, attrs[0], attrs[1], content, params)
+	}
+	logger.Debug("[client.updateLocalConfig] update config complete")
+}
 
-			}
-		} else if len(attrs) == 3 {
-			content, err := client.getConfigInner(vo.ConfigParam{
-				DataId: attrs[0],
-				Group:  attrs[1],
-			})
-			if err != nil {
-				// log.Println("[client.updateLocalConfig] update config failed:", err.Error())
-			} else {
-				client.putLocalConfig(vo.ConfigParam{
-					DataId:  attrs[0],
-					Group:   attrs[1],
-					Content: content,
-				})
-
-				// call listener:
-				decrept, _ := client.decrypt(attrs[0], content)
-				param.OnChange(attrs[2], attrs[1], attrs[0], decrept)
-			}
+func (client *ConfigClient) notifyListeners(tenant, dataId, group, content string, params []vo.ConfigParam) {
+	decrept, err := client.decrypt(dataId, content)
+	if err != nil {
+		logger.Error("[client.notifyListeners] decrypt config failed, skip listeners", logger.F("dataId", dataId),
+			logger.F("group", group), logger.F("tenant", tenant), logger.Err(err))
+		return
+	}
+	for _, param := range params {
+		if param.DataId == dataId && param.Group == group && param.OnChange != nil {
+			param.OnChange(tenant, group, dataId, decrept)
 		}
 	}
-	// log.Println("[client.updateLocalConfig] update config complete")
-	// log.Println("[client.localConfig] ", client.localConfigs)
 }
 
 func (client *ConfigClient) putLocalConfig(config vo.ConfigParam) {
@@ -372,7 +621,46 @@ func (client *ConfigClient) putLocalConfig(config vo.ConfigParam) {
 			client.localConfigs = append(client.localConfigs, config)
 		}
 	}
-	// log.Println("[client.putLocalConfig] putLocalConfig success")
+	logger.Debug("[client.putLocalConfig] putLocalConfig success", logger.F("dataId", config.DataId), logger.F("group", config.Group))
+}
+
+// 关闭客户端，停止全部监听任务并释放内存中的配置
+func (client *ConfigClient) Close() error {
+	client.listenerManager.Close()
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.localConfigs = nil
+	for _, key := range client.configInfos.Keys() {
+		client.configInfos.Remove(key)
+	}
+	for _, key := range client.failovers.Keys() {
+		client.failovers.Remove(key)
+	}
+	return nil
+}
+
+// forgetConfig 配置不再监听后，移除本地保存的内容、配置元信息和故障转移状态
+func (client *ConfigClient) forgetConfig(tenant string, param vo.ConfigParam) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.forgetConfigLocked(tenant, param)
+}
+
+// forgetConfigLocked 调用方需持有 mutex，与 updateLocalConfig 写入缓存前的检查互斥
+func (client *ConfigClient) forgetConfigLocked(tenant string, param vo.ConfigParam) {
+	client.removeLocalConfig(param)
+	cacheKey := utils.GetConfigCacheKey(param.DataId, param.Group, tenant)
+	client.configInfos.Remove(cacheKey)
+	client.failovers.Remove(cacheKey)
+}
+
+func (client *ConfigClient) removeLocalConfig(config vo.ConfigParam) {
+	for i := 0; i < len(client.localConfigs); i++ {
+		if config.DataId == client.localConfigs[i].DataId && config.Group == client.localConfigs[i].Group {
+			client.localConfigs = append(client.localConfigs[:i], client.localConfigs[i+1:]...)
+			break
+		}
+	}
 }
 
 func (client *ConfigClient) buildBasePath(serverConfig constant.ServerConfig) (basePath string) {
@@ -1,6 +1,10 @@
 package config_client
 
 import (
+	"context"
+
+	"github.com/uugtv/nacos-sdk-go/common/security"
+	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/vo"
 )
 
@@ -22,6 +26,19 @@ type IConfigClient interface {
 	// tenant ==>nacos.namespace optional
 	GetConfig(param vo.ConfigParam) (string, error)
 
+	// 获取配置，ctx 取消或超时后立即返回
+	GetConfigWithContext(ctx context.Context, param vo.ConfigParam) (string, error)
+
+	// 获取配置并解码到 target（结构体或 map 的指针）
+	// 格式由 param.Format 指定（json、yaml、toml、properties），为空时根据 dataId 后缀和内容识别
+	// 解码失败时返回错误，target 保持不变
+	GetConfigInto(param vo.ConfigParam, target interface{}) error
+
+	// 获取客户端当前持有的配置元信息：md5、最近修改时间、提供配置的服务端以及来源（服务端或本地缓存）
+	// dataId  require
+	// group   require
+	GetConfigInfo(param vo.ConfigParam) (model.ConfigInfo, error)
+
 	// 发布配置
 	// dataId  require
 	// group   require
@@ -29,15 +46,41 @@ type IConfigClient interface {
 	// tenant ==>nacos.namespace optional
 	PublishConfig(param vo.ConfigParam) (bool, error)
 
+	// 发布配置，ctx 取消或超时后立即返回
+	PublishConfigWithContext(ctx context.Context, param vo.ConfigParam) (bool, error)
+
 	// 删除配置
 	// dataId  require
 	// group   require
 	// tenant ==>nacos.namespace optional
 	DeleteConfig(param vo.ConfigParam) (bool, error)
 
+	// 删除配置，ctx 取消或超时后立即返回
+	DeleteConfigWithContext(ctx context.Context, param vo.ConfigParam) (bool, error)
+
 	// 监听配置
 	// dataId  require
 	// group   require
 	// tenant ==>nacos.namespace optional
+	// 设置 OnTypedChange 和 Target 时，变更内容会被解码后回调，解码失败时回调错误
 	ListenConfig(params vo.ConfigParam) (err error)
+
+	// 取消监听配置，同一配置上的全部监听都会被移除
+	// dataId  require
+	// group   require
+	// tenant ==>nacos.namespace optional
+	CancelListenConfig(params vo.ConfigParam) (err error)
+
+	// 以 channel 的形式监听配置，ctx 取消或客户端关闭后取消本次监听并关闭 channel
+	// dataId  require
+	// group   require
+	// 设置 Target 时，事件中的 Value 为解码后的新对象
+	WatchConfig(ctx context.Context, params vo.ConfigParam) (<-chan model.ConfigChangeEvent, error)
+
+	// 为以 prefix 开头的 dataId 注册加解密实现，多个前缀匹配时取最长的前缀
+	// 发布配置时加密内容，获取和监听配置时解密内容
+	RegisterCipherProvider(prefix string, provider security.CipherProvider)
+
+	// 关闭客户端，停止所有监听
+	Close() error
 }
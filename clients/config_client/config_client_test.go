@@ -1,17 +1,24 @@
 package config_client
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/clients/cache"
 	"github.com/uugtv/nacos-sdk-go/clients/nacos_client"
 	"github.com/uugtv/nacos-sdk-go/common/constant"
 	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/common/security"
 	"github.com/uugtv/nacos-sdk-go/mock"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/utils"
 	"github.com/uugtv/nacos-sdk-go/vo"
 )
 
@@ -140,7 +147,7 @@ func Test_GetConfigWithErrorResponse_401(t *testing.T) {
 	defer controller.Finish()
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodGet),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -156,7 +163,7 @@ func Test_GetConfigWithErrorResponse_404(t *testing.T) {
 	defer controller.Finish()
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodGet),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -173,7 +180,7 @@ func Test_GetConfigWithErrorResponse_403(t *testing.T) {
 	defer controller.Finish()
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodGet),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -192,7 +199,7 @@ func Test_GetConfigWithCache(t *testing.T) {
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
 
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodGet),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -202,7 +209,7 @@ func Test_GetConfigWithCache(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "content", content)
 
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodGet),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -264,7 +271,7 @@ func Test_PublishConfigWithErrorResponse(t *testing.T) {
 
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodPost),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -281,7 +288,7 @@ func Test_PublishConfigWithErrorResponse_200(t *testing.T) {
 
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodPost),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -321,7 +328,7 @@ func Test_DeleteConfigWithErrorResponse_200(t *testing.T) {
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
 
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodDelete),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodDelete),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -339,7 +346,7 @@ func Test_DeleteConfigWithErrorResponse_401(t *testing.T) {
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
 
-	mockHttpAgent.EXPECT().Request(gomock.Eq(http.MethodDelete),
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodDelete),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -427,7 +434,7 @@ func Test_listenConfigTask_NoChange(t *testing.T) {
 
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
 	client := cretateConfigClientHttpTest(mockHttpAgent)
-	mockHttpAgent.EXPECT().Post(
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs/listener"),
 		gomock.AssignableToTypeOf(headerTest),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -453,7 +460,7 @@ func Test_listenConfigTask_Change_WithTenant(t *testing.T) {
 	defer controller.Finish()
 	client := cretateConfigClientTest()
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
-	mockHttpAgent.EXPECT().Post(
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs/listener"),
 		gomock.AssignableToTypeOf(headerTest),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -493,7 +500,7 @@ func Test_listenConfigTask_Change_NoTenant(t *testing.T) {
 	defer controller.Finish()
 	client := cretateConfigClientTest()
 	mockHttpAgent := mock.NewMockIHttpAgent(controller)
-	mockHttpAgent.EXPECT().Post(
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs/listener"),
 		gomock.AssignableToTypeOf(headerTest),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -554,7 +561,7 @@ func Test_listen(t *testing.T) {
 		"Listening-Configs": "dataIdgroup9a0364b9e99bb480dd25e1f0284c8555tenant",
 	}
 	changedString := "dataId%02group%02tenant%01"
-	mockHttpAgent.EXPECT().Post(
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
 		gomock.Eq(path),
 		gomock.AssignableToTypeOf(headerTest),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -563,7 +570,7 @@ func Test_listen(t *testing.T) {
 
 	_ = client.SetHttpAgent(mockHttpAgent)
 
-	changed, err := listen(mockHttpAgent, path, clientConfigTest.TimeoutMs, clientConfigTest.ListenInterval, param)
+	changed, err := listen(context.Background(), mockHttpAgent, path, clientConfigTest.TimeoutMs, clientConfigTest.ListenInterval, param)
 	assert.Equal(t, changed, changedString)
 	assert.Nil(t, err)
 }
@@ -577,7 +584,7 @@ func Test_listenWithErrorResponse_401(t *testing.T) {
 	param := map[string]string{
 		"Listening-Configs": "dataIdgroup9a0364b9e99bb480dd25e1f0284c8555tenant",
 	}
-	mockHttpAgent.EXPECT().Post(
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
 		gomock.Eq(path),
 		gomock.AssignableToTypeOf(headerTest),
 		gomock.Eq(clientConfigTest.TimeoutMs),
@@ -586,7 +593,7 @@ func Test_listenWithErrorResponse_401(t *testing.T) {
 
 	_ = client.SetHttpAgent(mockHttpAgent)
 
-	_, err := listen(mockHttpAgent, path, clientConfigTest.TimeoutMs, clientConfigTest.ListenInterval, param)
+	_, err := listen(context.Background(), mockHttpAgent, path, clientConfigTest.TimeoutMs, clientConfigTest.ListenInterval, param)
 	assert.NotNil(t, err)
 }
 
@@ -653,3 +660,189 @@ func Test_AddConfigToListen(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, resultConfigs, client.localConfigs)
 }
+
+func Test_GetConfigWithContextCancelled(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(configParamMapTest),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, "content"), nil)
+	content, err := client.GetConfig(configParamTest)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", content)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(configParamMapTest),
+	).AnyTimes().Return(nil, context.Canceled)
+	content, err = client.GetConfigWithContext(ctx, configParamTest)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, "", content)
+}
+
+func Test_GetConfigInfo(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+
+	_, err := client.GetConfigInfo(configParamTest)
+	assert.NotNil(t, err)
+
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(configParamMapTest),
+	).Times(2).Return(http_agent.FakeHttpResponse(200, "content"), nil)
+	_, err = client.GetConfig(configParamTest)
+	assert.Nil(t, err)
+	info, err := client.GetConfigInfo(configParamTest)
+	assert.Nil(t, err)
+	assert.Equal(t, "dataId", info.DataId)
+	assert.Equal(t, "group", info.Group)
+	assert.Equal(t, "9a0364b9e99bb480dd25e1f0284c8555", info.Md5)
+	assert.Equal(t, "console.nacos.io:80", info.Server)
+	assert.Equal(t, model.CONFIG_SOURCE_SERVER, info.Source)
+
+	// 内容不变时修改时间保持不变
+	_, err = client.GetConfig(configParamTest)
+	assert.Nil(t, err)
+	info2, _ := client.GetConfigInfo(configParamTest)
+	assert.Equal(t, info.LastModified, info2.LastModified)
+
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(configParamMapTest),
+	).Times(3).Return(http_agent.FakeHttpResponse(401, "no auth"), nil)
+	_, err = client.GetConfig(configParamTest)
+	assert.Nil(t, err)
+	info, err = client.GetConfigInfo(configParamTest)
+	assert.Nil(t, err)
+	assert.Equal(t, model.CONFIG_SOURCE_CACHE, info.Source)
+	assert.Equal(t, "", info.Server)
+	assert.Equal(t, "9a0364b9e99bb480dd25e1f0284c8555", info.Md5)
+}
+
+func Test_GetConfigWithFailover(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+	dir, err := ioutil.TempDir("", "nacos-failover")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	client.failoverDir = dir
+
+	cacheKey := utils.GetConfigCacheKey("dataId", "group", "")
+	cache.WriteConfigToFile(cacheKey, dir, "failover")
+	content, err := client.GetConfig(configParamTest)
+	assert.Nil(t, err)
+	assert.Equal(t, "failover", content)
+	info, _ := client.GetConfigInfo(configParamTest)
+	assert.Equal(t, model.CONFIG_SOURCE_FAILOVER, info.Source)
+
+	// 故障转移生效时监听不请求服务端，直接回调文件内容
+	configData := ""
+	client.listenConfigTask(clientConfigTest, serverConfigsTest, mockHttpAgent, vo.ConfigParam{
+		DataId:  "dataId",
+		Group:   "group",
+		Content: "content",
+		OnChange: func(namespace, group, dataId, data string) {
+			configData = data
+		},
+	})
+	assert.Equal(t, "failover", configData)
+
+	assert.Nil(t, os.Remove(cache.GetFileName(cacheKey, dir)))
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(configParamMapTest),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, "content"), nil)
+	content, err = client.GetConfig(configParamTest)
+	assert.Nil(t, err)
+	assert.Equal(t, "content", content)
+}
+
+func Test_PublishAndGetConfigWithCipher(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+	provider, err := security.NewAesGcmProvider([]byte("0123456789abcdef"))
+	assert.Nil(t, err)
+	client.RegisterCipherProvider("cipher-", provider)
+
+	stored := ""
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any(),
+	).Times(1).DoAndReturn(func(ctx context.Context, method, path string, header http.Header, timeoutMs uint64,
+		params map[string]string) (*http.Response, error) {
+		stored = params["content"]
+		return http_agent.FakeHttpResponse(200, "true"), nil
+	})
+	param := vo.ConfigParam{DataId: "cipher-db", Group: "group", Content: "password=secret"}
+	success, err := client.PublishConfig(param)
+	assert.Nil(t, err)
+	assert.True(t, success)
+	assert.NotEqual(t, "", stored)
+	assert.NotContains(t, stored, "secret")
+
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(map[string]string{"dataId": "cipher-db", "group": "group"}),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, stored), nil)
+	content, err := client.GetConfig(vo.ConfigParam{DataId: "cipher-db", Group: "group"})
+	assert.Nil(t, err)
+	assert.Equal(t, "password=secret", content)
+}
+
+func Test_NewConfigClientWithKMSAndCipherKeyFile(t *testing.T) {
+	nc := nacos_client.NacosClient{}
+	nc.SetServerConfig([]constant.ServerConfig{serverConfigTest})
+	clientConfig := clientConfigTest
+	clientConfig.OpenKMS = true
+	clientConfig.CipherKeyFile = "cipher.key"
+	nc.SetClientConfig(clientConfig)
+	nc.SetHttpAgent(&http_agent.HttpAgent{})
+	_, err := NewConfigClient(&nc)
+	assert.NotNil(t, err)
+}
+
+func Test_NotifyListenersDecryptFailed(t *testing.T) {
+	client := cretateConfigClientTest()
+	provider, err := security.NewAesGcmProvider([]byte("0123456789abcdef"))
+	assert.Nil(t, err)
+	client.RegisterCipherProvider("cipher-", provider)
+
+	called := false
+	client.notifyListeners("", "cipher-db", "group", "not encrypted", []vo.ConfigParam{{DataId: "cipher-db", Group: "group",
+		OnChange: func(namespace, group, dataId, data string) {
+			called = true
+		}}})
+	// 解密失败时不回调，避免监听收到空的配置
+	assert.False(t, called)
+}
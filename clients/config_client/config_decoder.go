@@ -0,0 +1,383 @@
+package config_client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	tomlTablePattern = regexp.MustCompile(`^\[\[?[A-Za-z0-9_.\-"' ]+\]\]?$`)
+	yamlKeyPattern   = regexp.MustCompile(`^[A-Za-z0-9_.\-"']+:(\s|$)`)
+)
+
+// DetectConfigFormat 根据 dataId 后缀识别配置格式，无法识别时根据内容判断
+func DetectConfigFormat(dataId string, content string) string {
+	switch strings.ToLower(filepath.Ext(dataId)) {
+	case ".json":
+		return constant.CONFIG_FORMAT_JSON
+	case ".yaml", ".yml":
+		return constant.CONFIG_FORMAT_YAML
+	case ".toml":
+		return constant.CONFIG_FORMAT_TOML
+	case ".properties":
+		return constant.CONFIG_FORMAT_PROPERTIES
+	}
+
+	trimmed := strings.TrimSpace(content)
+	// toml 的表头同样以 [ 开头
+	if strings.HasPrefix(trimmed, "{") || (strings.HasPrefix(trimmed, "[") && json.Valid([]byte(trimmed))) {
+		return constant.CONFIG_FORMAT_JSON
+	}
+	if strings.HasPrefix(trimmed, "---") {
+		return constant.CONFIG_FORMAT_YAML
+	}
+	scanner := bufio.NewScanner(strings.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		if tomlTablePattern.MatchString(line) {
+			return constant.CONFIG_FORMAT_TOML
+		}
+		if yamlKeyPattern.MatchString(line) || strings.HasPrefix(line, "- ") {
+			return constant.CONFIG_FORMAT_YAML
+		}
+		break
+	}
+	// key = value 形式同时是合法的 properties，toml 要求字符串带引号，能按 toml 解析时视为 toml
+	var probe map[string]interface{}
+	if _, err := toml.Decode(trimmed, &probe); err == nil && len(probe) > 0 {
+		return constant.CONFIG_FORMAT_TOML
+	}
+	return constant.CONFIG_FORMAT_PROPERTIES
+}
+
+// DecodeConfig 将配置内容按 format 解码到 target（结构体或 map 的指针），format 为空时自动识别
+func DecodeConfig(dataId string, content string, format string, target interface{}) error {
+	if target == nil || reflect.TypeOf(target).Kind() != reflect.Ptr || reflect.ValueOf(target).IsNil() {
+		return errors.New("[client.DecodeConfig] target must be a non-nil pointer")
+	}
+	if format == "" {
+		format = DetectConfigFormat(dataId, content)
+	}
+	var err error
+	switch strings.ToLower(format) {
+	case constant.CONFIG_FORMAT_JSON:
+		err = json.Unmarshal([]byte(content), target)
+	case constant.CONFIG_FORMAT_YAML, "yml":
+		err = yaml.Unmarshal([]byte(content), target)
+	case constant.CONFIG_FORMAT_TOML:
+		_, err = toml.Decode(content, target)
+	case constant.CONFIG_FORMAT_PROPERTIES:
+		var props map[string]string
+		props, err = parseProperties(content)
+		if err == nil {
+			err = decodeProperties(props, reflect.ValueOf(target).Elem(), "")
+		}
+	default:
+		return errors.New("[client.DecodeConfig] unknown config format:" + format)
+	}
+	if err != nil {
+		return errors.New("[client.DecodeConfig] decode " + format + " config failed:" + err.Error())
+	}
+	return nil
+}
+
+// 解码到一个与 target 同类型的新对象，失败时不影响 target
+func decodeConfigNew(dataId string, content string, format string, target interface{}) (interface{}, error) {
+	if target == nil || reflect.TypeOf(target).Kind() != reflect.Ptr {
+		return nil, errors.New("[client.DecodeConfig] target must be a non-nil pointer")
+	}
+	value := reflect.New(reflect.TypeOf(target).Elem()).Interface()
+	if err := DecodeConfig(dataId, content, format, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parseProperties 解析 java .properties 格式：支持 #/! 注释、=/:/空白分隔、行尾 \ 续行以及常见转义
+func parseProperties(content string) (map[string]string, error) {
+	props := map[string]string{}
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continued(line) {
+			line = line[:len(line)-1]
+		}
+		key, value := splitProperty(line)
+		unescapedKey, err := unescapeProperty(key)
+		if err != nil {
+			return nil, err
+		}
+		unescapedValue, err := unescapeProperty(value)
+		if err != nil {
+			return nil, err
+		}
+		props[unescapedKey] = unescapedValue
+	}
+	return props, nil
+}
+
+// 行尾有奇数个反斜杠时表示续行
+func continued(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if len(rest) > 0 && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
+		}
+	}
+	return line, ""
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", errors.New("malformed \\uxxxx encoding in:" + s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", errors.New("malformed \\uxxxx encoding in:" + s)
+			}
+			sb.WriteRune(rune(r))
+			i += 4
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decodeProperties 按 a.b.c 形式的 key 填充结构体，字段名取 properties 标签，其次 json 标签，最后为不区分大小写的字段名
+func decodeProperties(props map[string]string, value reflect.Value, prefix string) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			if !hasPrefix(props, prefix) {
+				return nil
+			}
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return decodeProperties(props, value.Elem(), prefix)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return errors.New("unsupported map key type:" + value.Type().Key().String())
+		}
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+		for k, v := range props {
+			if prefix != "" {
+				if !strings.HasPrefix(k, prefix+".") {
+					continue
+				}
+				k = strings.TrimPrefix(k, prefix+".")
+			}
+			elem := reflect.New(value.Type().Elem()).Elem()
+			if err := setPropertyValue(elem, v); err != nil {
+				return errors.New("property " + k + ":" + err.Error())
+			}
+			value.SetMapIndex(reflect.ValueOf(k).Convert(value.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Struct:
+		if value.Type() == reflect.TypeOf(time.Time{}) {
+			break
+		}
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := propertyName(field)
+			if name == "-" {
+				continue
+			}
+			key := name
+			if prefix != "" {
+				key = prefix + "." + name
+			}
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				key = prefix
+			}
+			fieldValue := value.Field(i)
+			kind := fieldValue.Kind()
+			if kind == reflect.Ptr {
+				kind = fieldValue.Type().Elem().Kind()
+			}
+			if kind == reflect.Struct || kind == reflect.Map {
+				if err := decodeProperties(props, fieldValue, key); err != nil {
+					return err
+				}
+				continue
+			}
+			raw, ok := lookupProperty(props, key)
+			if !ok {
+				continue
+			}
+			if err := setPropertyValue(fieldValue, raw); err != nil {
+				return errors.New("property " + key + ":" + err.Error())
+			}
+		}
+		return nil
+	}
+	raw, ok := props[prefix]
+	if !ok {
+		return nil
+	}
+	return setPropertyValue(value, raw)
+}
+
+func propertyName(field reflect.StructField) string {
+	for _, tagName := range []string{"properties", "json"} {
+		if tag := field.Tag.Get(tagName); tag != "" {
+			if name := strings.Split(tag, ",")[0]; name != "" {
+				return name
+			}
+		}
+	}
+	return field.Name
+}
+
+func lookupProperty(props map[string]string, key string) (string, bool) {
+	if v, ok := props[key]; ok {
+		return v, true
+	}
+	for k, v := range props {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func hasPrefix(props map[string]string, prefix string) bool {
+	if prefix == "" {
+		return len(props) > 0
+	}
+	for k := range props {
+		if k == prefix || len(k) > len(prefix) && strings.EqualFold(k[:len(prefix)+1], prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func setPropertyValue(value reflect.Value, raw string) error {
+	if value.Kind() == reflect.Ptr {
+		elem := reflect.New(value.Type().Elem())
+		if err := setPropertyValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+		value.Set(elem)
+		return nil
+	}
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(raw), 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		if strings.TrimSpace(raw) != "" {
+			items = strings.Split(raw, ",")
+		}
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setPropertyValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	case reflect.Interface:
+		value.Set(reflect.ValueOf(raw))
+	default:
+		return errors.New("unsupported type:" + value.Type().String())
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package config_client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/mock"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+type dbConfig struct {
+	Host    string        `json:"host" yaml:"host" toml:"host"`
+	Port    int           `json:"port" yaml:"port" toml:"port"`
+	Timeout time.Duration `json:"timeout" yaml:"timeout" toml:"timeout" properties:"timeout"`
+}
+
+type appConfig struct {
+	Name  string   `json:"name" yaml:"name" toml:"name"`
+	Debug bool     `json:"debug" yaml:"debug" toml:"debug"`
+	Tags  []string `json:"tags" yaml:"tags" toml:"tags"`
+	DB    dbConfig `json:"db" yaml:"db" toml:"db"`
+}
+
+func TestDetectConfigFormat(t *testing.T) {
+	assert.Equal(t, constant.CONFIG_FORMAT_JSON, DetectConfigFormat("app.json", ""))
+	assert.Equal(t, constant.CONFIG_FORMAT_YAML, DetectConfigFormat("app.yml", ""))
+	assert.Equal(t, constant.CONFIG_FORMAT_TOML, DetectConfigFormat("app.toml", ""))
+	assert.Equal(t, constant.CONFIG_FORMAT_PROPERTIES, DetectConfigFormat("app.properties", ""))
+
+	assert.Equal(t, constant.CONFIG_FORMAT_JSON, DetectConfigFormat("app", `{"name":"demo"}`))
+	assert.Equal(t, constant.CONFIG_FORMAT_YAML, DetectConfigFormat("app", "# comment\nname: demo\n"))
+	assert.Equal(t, constant.CONFIG_FORMAT_TOML, DetectConfigFormat("app", "[db]\nhost = \"localhost\"\n"))
+	assert.Equal(t, constant.CONFIG_FORMAT_TOML, DetectConfigFormat("app", "name = \"demo\"\n"))
+	assert.Equal(t, constant.CONFIG_FORMAT_PROPERTIES, DetectConfigFormat("app", "name=demo\ndb.host=localhost\n"))
+}
+
+func TestDecodeConfig(t *testing.T) {
+	expected := appConfig{
+		Name:  "demo",
+		Debug: true,
+		Tags:  []string{"a", "b"},
+		DB:    dbConfig{Host: "localhost", Port: 3306, Timeout: 3 * time.Second},
+	}
+	contents := map[string]string{
+		constant.CONFIG_FORMAT_JSON: `{"name":"demo","debug":true,"tags":["a","b"],"db":{"host":"localhost","port":3306,"timeout":3000000000}}`,
+		constant.CONFIG_FORMAT_YAML: "name: demo\ndebug: true\ntags:\n  - a\n  - b\ndb:\n  host: localhost\n  port: 3306\n  timeout: 3000000000\n",
+		constant.CONFIG_FORMAT_TOML: "name = \"demo\"\ndebug = true\ntags = [\"a\", \"b\"]\n[db]\nhost = \"localhost\"\nport = 3306\ntimeout = 3000000000\n",
+		constant.CONFIG_FORMAT_PROPERTIES: "# app\nname=demo\ndebug = true\ntags=a, b\ndb.host:localhost\n" +
+			"db.port 3306\ndb.timeout=3s\n",
+	}
+	for format, content := range contents {
+		var config appConfig
+		assert.Nil(t, DecodeConfig("app", content, format, &config), format)
+		assert.Equal(t, expected, config, format)
+
+		// 自动识别格式
+		config = appConfig{}
+		assert.Nil(t, DecodeConfig("app", content, "", &config), format)
+		assert.Equal(t, expected, config, format)
+	}
+}
+
+func TestDecodeConfig_Properties(t *testing.T) {
+	props := map[string]string{}
+	err := DecodeConfig("app.properties", "a.b=1\nmessage=hello \\\n    world\nunicode=\\u4f60\\u597d\n! comment\n", "", &props)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a.b": "1", "message": "hello world", "unicode": "你好"}, props)
+
+	var config appConfig
+	err = DecodeConfig("app.properties", "db.port=abc", "", &config)
+	assert.NotNil(t, err)
+}
+
+func TestDecodeConfig_InvalidTarget(t *testing.T) {
+	var config appConfig
+	assert.NotNil(t, DecodeConfig("app.json", "{}", "", config))
+	assert.NotNil(t, DecodeConfig("app.json", "{}", "xml", &config))
+}
+
+func Test_GetConfigInto(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+	param := vo.ConfigParam{DataId: "app.json", Group: "group"}
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(map[string]string{"dataId": "app.json", "group": "group"}),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, `{"name":"demo"}`), nil)
+	var config appConfig
+	assert.Nil(t, client.GetConfigInto(param, &config))
+	assert.Equal(t, "demo", config.Name)
+
+	// 内容无法解码时保留原来的值
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(map[string]string{"dataId": "app.json", "group": "group"}),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, `{"name":`), nil)
+	assert.NotNil(t, client.GetConfigInto(param, &config))
+	assert.Equal(t, "demo", config.Name)
+}
+
+func Test_typedOnChange(t *testing.T) {
+	var values []interface{}
+	var errs []error
+	raw := 0
+	onChange := typedOnChange(vo.ConfigParam{
+		DataId: "app.yaml",
+		Group:  "group",
+		Target: &appConfig{},
+		OnChange: func(namespace, group, dataId, data string) {
+			raw++
+		},
+		OnTypedChange: func(namespace, group, dataId string, value interface{}, err error) {
+			values = append(values, value)
+			errs = append(errs, err)
+		},
+	})
+	onChange("", "group", "app.yaml", "name: demo\n")
+	onChange("", "group", "app.yaml", "name: [demo\n")
+
+	assert.Equal(t, 2, raw)
+	assert.Equal(t, 2, len(values))
+	assert.Equal(t, &appConfig{Name: "demo"}, values[0])
+	assert.Nil(t, errs[0])
+	assert.Nil(t, values[1])
+	assert.NotNil(t, errs[1])
+}
+
+func TestListenConfig_TypedWithoutTarget(t *testing.T) {
+	client := cretateConfigClientTest()
+	err := client.ListenConfig(vo.ConfigParam{
+		DataId: "app.yaml",
+		Group:  "group",
+		OnTypedChange: func(namespace, group, dataId string, value interface{}, err error) {
+		},
+	})
+	assert.NotNil(t, err)
+}
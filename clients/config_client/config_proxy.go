@@ -1,6 +1,7 @@
 package config_client
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -28,7 +29,8 @@ func (cp *ConfigProxy) GetServerList() []constant.ServerConfig {
 	return cp.nacosServer.GetServerList()
 }
 
-func (cp *ConfigProxy) GetConfigProxy(param vo.ConfigParam, tenant, accessKey, secretKey string) (string, error) {
+// GetConfigProxy 返回配置内容以及提供该配置的服务端地址
+func (cp *ConfigProxy) GetConfigProxy(ctx context.Context, param vo.ConfigParam, tenant, accessKey, secretKey string) (string, string, error) {
 	params := util.TransformObject2Param(param)
 	if len(tenant) > 0 {
 		params["tenant"] = tenant
@@ -38,11 +40,10 @@ func (cp *ConfigProxy) GetConfigProxy(param vo.ConfigParam, tenant, accessKey, s
 	headers["accessKey"] = accessKey
 	headers["secretKey"] = secretKey
 
-	result, err := cp.nacosServer.ReqConfigApi(constant.CONFIG_PATH, params, headers, http.MethodGet)
-	return result, err
+	return cp.nacosServer.ReqConfigApiWithServer(ctx, constant.CONFIG_PATH, params, headers, http.MethodGet)
 }
 
-func (cp *ConfigProxy) PublishConfigProxy(param vo.ConfigParam, tenant, accessKey, secretKey string) (bool, error) {
+func (cp *ConfigProxy) PublishConfigProxy(ctx context.Context, param vo.ConfigParam, tenant, accessKey, secretKey string) (bool, error) {
 	params := util.TransformObject2Param(param)
 	if len(tenant) > 0 {
 		params["tenant"] = tenant
@@ -51,7 +52,7 @@ func (cp *ConfigProxy) PublishConfigProxy(param vo.ConfigParam, tenant, accessKe
 	var headers = map[string]string{}
 	headers["accessKey"] = accessKey
 	headers["secretKey"] = secretKey
-	result, err := cp.nacosServer.ReqConfigApi(constant.CONFIG_PATH, params, headers, http.MethodPost)
+	result, err := cp.nacosServer.ReqConfigApiWithContext(ctx, constant.CONFIG_PATH, params, headers, http.MethodPost)
 	if err != nil {
 		return false, errors.New("[client.PublishConfig] publish config failed:" + err.Error())
 	}
@@ -62,7 +63,7 @@ func (cp *ConfigProxy) PublishConfigProxy(param vo.ConfigParam, tenant, accessKe
 	}
 }
 
-func (cp *ConfigProxy) DeleteConfigProxy(param vo.ConfigParam, tenant, accessKey, secretKey string) (bool, error) {
+func (cp *ConfigProxy) DeleteConfigProxy(ctx context.Context, param vo.ConfigParam, tenant, accessKey, secretKey string) (bool, error) {
 	params := util.TransformObject2Param(param)
 	if len(tenant) > 0 {
 		params["tenant"] = tenant
@@ -70,7 +71,7 @@ func (cp *ConfigProxy) DeleteConfigProxy(param vo.ConfigParam, tenant, accessKey
 	var headers = map[string]string{}
 	headers["accessKey"] = accessKey
 	headers["secretKey"] = secretKey
-	result, err := cp.nacosServer.ReqConfigApi(constant.CONFIG_PATH, params, headers, http.MethodDelete)
+	result, err := cp.nacosServer.ReqConfigApiWithContext(ctx, constant.CONFIG_PATH, params, headers, http.MethodDelete)
 	if err != nil {
 		return false, errors.New("[client.DeleteConfig] deleted config failed:" + err.Error())
 	}
@@ -0,0 +1,123 @@
+package config_client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/uugtv/nacos-sdk-go/common/watcher"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+// configWatcher 将配置变更写入 channel，不阻塞长轮询的线程：channel 已满时事件暂存，由单独的线程在读取后写入
+// 配置事件携带完整的内容，暂存期间只保留最新的一个事件
+type configWatcher struct {
+	guard    *watcher.Guard
+	events   chan model.ConfigChangeEvent
+	mutex    sync.Mutex
+	pending  *model.ConfigChangeEvent
+	draining bool
+}
+
+func newConfigWatcher() *configWatcher {
+	return &configWatcher{guard: watcher.NewGuard(), events: make(chan model.ConfigChangeEvent, watcher.Default_Watch_Chan_Size)}
+}
+
+func (w *configWatcher) offer(event model.ConfigChangeEvent) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.draining {
+		// 之前的事件还在等待写入，保持顺序，替换暂存的事件
+		w.pending = &event
+		return
+	}
+	sent := false
+	w.guard.Send(func(done <-chan struct{}) {
+		select {
+		case w.events <- event:
+			sent = true
+		default:
+		}
+	})
+	if sent {
+		return
+	}
+	w.pending = &event
+	w.draining = true
+	go w.drain()
+}
+
+// drain 将暂存的事件写入 channel，channel 满时等待读取，监听取消后退出
+func (w *configWatcher) drain() {
+	for {
+		w.mutex.Lock()
+		event := w.pending
+		w.pending = nil
+		if event == nil {
+			w.draining = false
+			w.mutex.Unlock()
+			return
+		}
+		w.mutex.Unlock()
+		w.guard.Send(func(done <-chan struct{}) {
+			select {
+			case w.events <- *event:
+			case <-done:
+			}
+		})
+	}
+}
+
+func (w *configWatcher) close() {
+	w.guard.Close(func() {
+		close(w.events)
+	})
+}
+
+// WatchConfig 监听配置，变更以事件的形式写入返回的 channel
+// ctx 取消或客户端关闭后取消监听并关闭 channel，只移除本次的监听，不影响同一配置上的其它监听
+func (client *ConfigClient) WatchConfig(ctx context.Context, param vo.ConfigParam) (<-chan model.ConfigChangeEvent, error) {
+	if len(param.DataId) <= 0 {
+		return nil, errors.New("[client.WatchConfig] DataId can not be empty")
+	}
+	if len(param.Group) <= 0 {
+		return nil, errors.New("[client.WatchConfig] Group can not be empty")
+	}
+	if param.Target != nil && reflect.TypeOf(param.Target).Kind() != reflect.Ptr {
+		return nil, errors.New("[client.WatchConfig] Target must be a pointer")
+	}
+	if client.listenerManager.IsClosed() {
+		return nil, errors.New("[client.WatchConfig] client is closed")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	configWatcher := newConfigWatcher()
+	param.OnTypedChange = nil
+	param.OnChange = func(namespace, group, dataId, data string) {
+		event := model.ConfigChangeEvent{Namespace: namespace, Group: group, DataId: dataId, Content: data}
+		if param.Target != nil {
+			event.Value, event.Err = decodeConfigNew(dataId, data, param.Format, param.Target)
+		}
+		configWatcher.offer(event)
+	}
+
+	clientConfig, _ := client.GetClientConfig()
+	taskId, start, entry := client.listenerManager.addListener(clientConfig.NamespaceId, param)
+	if start {
+		go client.runListenTask(taskId)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-client.listenerManager.Done():
+		}
+		if client.listenerManager.removeEntry(clientConfig.NamespaceId, param, entry) {
+			client.forgetConfig(clientConfig.NamespaceId, param)
+		}
+		configWatcher.close()
+	}()
+	return configWatcher.events, nil
+}
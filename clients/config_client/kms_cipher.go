@@ -0,0 +1,53 @@
+package config_client
+
+import (
+	"errors"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/kms"
+)
+
+// kmsApi 为 kms.Client 中用到的方法，便于测试时替换
+type kmsApi interface {
+	Encrypt(request *kms.EncryptRequest) (*kms.EncryptResponse, error)
+	Decrypt(request *kms.DecryptRequest) (*kms.DecryptResponse, error)
+}
+
+// kmsCipherProvider 使用阿里云 KMS 加解密配置，加密时需要指定 KMS 密钥 ID
+type kmsCipherProvider struct {
+	kmsClient kmsApi
+	keyId     string
+}
+
+func newKmsCipherProvider(kmsClient kmsApi, keyId string) *kmsCipherProvider {
+	return &kmsCipherProvider{kmsClient: kmsClient, keyId: keyId}
+}
+
+func (p *kmsCipherProvider) Encrypt(dataId string, plainText string) (string, error) {
+	if len(p.keyId) <= 0 {
+		return "", errors.New("[client.kmsCipher] kms encrypt failed:ClientConfig.KMSKeyId is not set")
+	}
+	request := kms.CreateEncryptRequest()
+	request.Method = "POST"
+	request.Scheme = "https"
+	request.AcceptFormat = "json"
+	request.KeyId = p.keyId
+	request.Plaintext = plainText
+	response, err := p.kmsClient.Encrypt(request)
+	if err != nil {
+		return "", errors.New("[client.kmsCipher] kms encrypt failed:" + err.Error())
+	}
+	return response.CiphertextBlob, nil
+}
+
+func (p *kmsCipherProvider) Decrypt(dataId string, cipherText string) (string, error) {
+	request := kms.CreateDecryptRequest()
+	request.Method = "POST"
+	request.Scheme = "https"
+	request.AcceptFormat = "json"
+	request.CiphertextBlob = cipherText
+	response, err := p.kmsClient.Decrypt(request)
+	if err != nil {
+		return "", errors.New("[client.kmsCipher] kms decrypt failed:" + err.Error())
+	}
+	return response.Plaintext, nil
+}
@@ -0,0 +1,78 @@
+package config_client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/kms"
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+type fakeKmsApi struct {
+	keyId string
+	err   error
+}
+
+func (f *fakeKmsApi) Encrypt(request *kms.EncryptRequest) (*kms.EncryptResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.keyId = request.KeyId
+	return &kms.EncryptResponse{CiphertextBlob: "encrypted:" + request.Plaintext}, nil
+}
+
+func (f *fakeKmsApi) Decrypt(request *kms.DecryptRequest) (*kms.DecryptResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &kms.DecryptResponse{Plaintext: request.CiphertextBlob[len("encrypted:"):]}, nil
+}
+
+func TestKmsCipherProvider(t *testing.T) {
+	api := &fakeKmsApi{}
+	provider := newKmsCipherProvider(api, "key-id")
+	cipherText, err := provider.Encrypt("cipher-db", "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "encrypted:secret", cipherText)
+	assert.Equal(t, "key-id", api.keyId)
+	plainText, err := provider.Decrypt("cipher-db", cipherText)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", plainText)
+
+	_, err = newKmsCipherProvider(api, "").Encrypt("cipher-db", "secret")
+	assert.NotNil(t, err)
+
+	api.err = errors.New("denied")
+	_, err = provider.Encrypt("cipher-db", "secret")
+	assert.NotNil(t, err)
+	_, err = provider.Decrypt("cipher-db", cipherText)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "kms decrypt failed:denied")
+}
+
+func Test_PublishConfigWithoutCipher(t *testing.T) {
+	client := cretateConfigClientTest()
+	_, err := client.PublishConfig(vo.ConfigParam{
+		DataId:  "cipher-db",
+		Group:   "group",
+		Content: "password=secret"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no cipher configured")
+
+	// 加密失败时不发布
+	client.RegisterCipherProvider("cipher-", newKmsCipherProvider(&fakeKmsApi{}, ""))
+	_, err = client.PublishConfig(vo.ConfigParam{
+		DataId:  "cipher-db",
+		Group:   "group",
+		Content: "password=secret"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "encrypt config failed")
+
+	// 参数校验先于加密
+	_, err = client.PublishConfig(vo.ConfigParam{
+		DataId:  "cipher-db",
+		Content: "password=secret"})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "param.group can not be empty")
+}
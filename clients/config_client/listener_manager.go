@@ -0,0 +1,221 @@
+package config_client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/utils"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+const Default_Per_Task_Config_Size = constant.PER_TASK_CONFIG_SIZE
+
+// ListenerManager 将所有监听的配置按 perTaskSize 分组，每组共用一个长轮询任务
+type ListenerManager struct {
+	mutex       sync.Mutex
+	perTaskSize int
+	keys        []string
+	listeners   map[string][]*listenEntry
+	taskIds     map[string]int
+	taskSizes   []int
+	running     map[int]bool
+	closed      bool
+	done        chan struct{}
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+type listenEntry struct {
+	param     vo.ConfigParam
+	cancelled bool
+}
+
+func NewListenerManager(perTaskSize int) *ListenerManager {
+	if perTaskSize <= 0 {
+		perTaskSize = Default_Per_Task_Config_Size
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ListenerManager{
+		perTaskSize: perTaskSize,
+		listeners:   map[string][]*listenEntry{},
+		taskIds:     map[string]int{},
+		running:     map[int]bool{},
+		done:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+func listenKey(dataId, group, tenant string) string {
+	return utils.GetConfigCacheKey(dataId, group, tenant)
+}
+
+// AddListener 注册监听，返回所属任务id，以及该任务是否需要启动
+func (lm *ListenerManager) AddListener(tenant string, param vo.ConfigParam) (taskId int, start bool) {
+	taskId, start, _ = lm.addListener(tenant, param)
+	return
+}
+
+// addListener 同 AddListener，额外返回监听本身，用于 removeEntry 只移除这一个监听
+func (lm *ListenerManager) addListener(tenant string, param vo.ConfigParam) (taskId int, start bool, entry *listenEntry) {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	key := listenKey(param.DataId, param.Group, tenant)
+	if _, ok := lm.listeners[key]; !ok {
+		taskId = lm.allocTask()
+		lm.taskIds[key] = taskId
+		lm.taskSizes[taskId]++
+		lm.keys = append(lm.keys, key)
+	}
+	entry = lm.newEntry(param)
+	lm.listeners[key] = append(lm.listeners[key], entry)
+	taskId = lm.taskIds[key]
+	if !lm.running[taskId] {
+		lm.running[taskId] = true
+		start = true
+	}
+	return
+}
+
+// 取消之后，已经在进行中的长轮询不再回调该监听
+func (lm *ListenerManager) newEntry(param vo.ConfigParam) *listenEntry {
+	entry := &listenEntry{param: param}
+	if onChange := param.OnChange; onChange != nil {
+		entry.param.OnChange = func(namespace, group, dataId, data string) {
+			lm.mutex.Lock()
+			cancelled := entry.cancelled
+			lm.mutex.Unlock()
+			if !cancelled {
+				onChange(namespace, group, dataId, data)
+			}
+		}
+	}
+	return entry
+}
+
+func (lm *ListenerManager) allocTask() int {
+	for id, size := range lm.taskSizes {
+		if size < lm.perTaskSize {
+			return id
+		}
+	}
+	lm.taskSizes = append(lm.taskSizes, 0)
+	return len(lm.taskSizes) - 1
+}
+
+// RemoveListener 移除某个配置上的全部监听，返回该配置之前是否在监听
+func (lm *ListenerManager) RemoveListener(tenant string, param vo.ConfigParam) bool {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	key := listenKey(param.DataId, param.Group, tenant)
+	entries, ok := lm.listeners[key]
+	if !ok {
+		return false
+	}
+	for _, entry := range entries {
+		entry.cancelled = true
+	}
+	lm.removeKeyLocked(key)
+	return true
+}
+
+// removeEntry 只移除 addListener 返回的监听，返回该配置上是否已经没有监听
+func (lm *ListenerManager) removeEntry(tenant string, param vo.ConfigParam, entry *listenEntry) bool {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	entry.cancelled = true
+	key := listenKey(param.DataId, param.Group, tenant)
+	entries, ok := lm.listeners[key]
+	if !ok {
+		return false
+	}
+	var remain []*listenEntry
+	for _, e := range entries {
+		if e != entry {
+			remain = append(remain, e)
+		}
+	}
+	if len(remain) > 0 {
+		lm.listeners[key] = remain
+		return false
+	}
+	lm.removeKeyLocked(key)
+	return true
+}
+
+func (lm *ListenerManager) removeKeyLocked(key string) {
+	lm.taskSizes[lm.taskIds[key]]--
+	delete(lm.listeners, key)
+	delete(lm.taskIds, key)
+	for i, k := range lm.keys {
+		if k == key {
+			lm.keys = append(lm.keys[:i], lm.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (lm *ListenerManager) HasListener(tenant string, dataId, group string) bool {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	_, ok := lm.listeners[listenKey(dataId, group, tenant)]
+	return ok
+}
+
+// TaskParams 返回某个任务负责的全部监听参数，同一配置的多个监听按注册顺序相邻
+// 任务已经没有监听时返回空，并将任务标记为停止，之后的 AddListener 会重新启动它
+func (lm *ListenerManager) TaskParams(taskId int) []vo.ConfigParam {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	var params []vo.ConfigParam
+	for _, key := range lm.keys {
+		if lm.taskIds[key] == taskId {
+			for _, entry := range lm.listeners[key] {
+				params = append(params, entry.param)
+			}
+		}
+	}
+	if len(params) == 0 {
+		lm.running[taskId] = false
+	}
+	return params
+}
+
+func (lm *ListenerManager) TaskCount() int {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	return len(lm.taskSizes)
+}
+
+// Close 停止全部监听任务，之后不再接受新的监听
+func (lm *ListenerManager) Close() {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	if lm.closed {
+		return
+	}
+	lm.closed = true
+	close(lm.done)
+	lm.cancel()
+	for _, entries := range lm.listeners {
+		for _, entry := range entries {
+			entry.cancelled = true
+		}
+	}
+}
+
+func (lm *ListenerManager) IsClosed() bool {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+	return lm.closed
+}
+
+func (lm *ListenerManager) Done() <-chan struct{} {
+	return lm.done
+}
+
+// Context 返回监听任务发起请求时使用的 context，Close 时取消，正在进行的长轮询随之中断
+func (lm *ListenerManager) Context() context.Context {
+	return lm.ctx
+}
@@ -0,0 +1,298 @@
+package config_client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/mock"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/utils"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+func TestListenerManager_AddListener(t *testing.T) {
+	lm := NewListenerManager(2)
+	taskId, start := lm.AddListener("", vo.ConfigParam{DataId: "a", Group: "group"})
+	assert.Equal(t, 0, taskId)
+	assert.True(t, start)
+
+	taskId, start = lm.AddListener("", vo.ConfigParam{DataId: "a", Group: "group"})
+	assert.Equal(t, 0, taskId)
+	assert.False(t, start)
+
+	taskId, start = lm.AddListener("", vo.ConfigParam{DataId: "b", Group: "group"})
+	assert.Equal(t, 0, taskId)
+	assert.False(t, start)
+
+	taskId, start = lm.AddListener("", vo.ConfigParam{DataId: "c", Group: "group"})
+	assert.Equal(t, 1, taskId)
+	assert.True(t, start)
+
+	assert.Equal(t, 2, lm.TaskCount())
+	assert.Equal(t, 3, len(lm.TaskParams(0)))
+	assert.Equal(t, 1, len(lm.TaskParams(1)))
+}
+
+func Test_listenConfigsTask_Batch(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs/listener"),
+		gomock.AssignableToTypeOf(headerTest),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(map[string]string{
+			constant.KEY_LISTEN_CONFIGS: "dataId" + constant.SPLIT_CONFIG_INNER + "group" + constant.SPLIT_CONFIG_INNER +
+				"9a0364b9e99bb480dd25e1f0284c8555" + constant.SPLIT_CONFIG +
+				"dataId2" + constant.SPLIT_CONFIG_INNER + "group" + constant.SPLIT_CONFIG_INNER + constant.SPLIT_CONFIG,
+		}),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, "dataId2%02group%01"), nil)
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(map[string]string{
+			"dataId": "dataId2",
+			"group":  "group",
+		}),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, "content2"), nil)
+
+	var changed []string
+	onChange := func(namespace, group, dataId, data string) {
+		changed = append(changed, dataId+":"+data)
+	}
+	params := []vo.ConfigParam{
+		{DataId: "dataId", Group: "group", Content: "content", OnChange: onChange},
+		{DataId: "dataId2", Group: "group", OnChange: onChange},
+		{DataId: "dataId2", Group: "group", OnChange: onChange},
+	}
+	for _, param := range params {
+		client.listenerManager.AddListener("", param)
+	}
+	client.listenConfigsTask(clientConfigTest, serverConfigsTest, mockHttpAgent, params)
+
+	assert.Equal(t, []string{"dataId2:content2", "dataId2:content2"}, changed)
+}
+
+func Test_listenConfigsTask_CancelledDuringQuery(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+	param := vo.ConfigParam{DataId: "dataId", Group: "group", Content: "content", OnChange: func(namespace, group, dataId, data string) {
+		t.Fatal("cancelled listener should not be called")
+	}}
+	client.listenerManager.AddListener("", param)
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs/listener"),
+		gomock.Any(), gomock.Any(), gomock.Any(),
+	).Times(1).Return(http_agent.FakeHttpResponse(200, "dataId%02group%01"), nil)
+	// 查询配置期间取消最后一个监听
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs"),
+		gomock.Any(), gomock.Any(), gomock.Any(),
+	).Times(1).DoAndReturn(func(ctx context.Context, method string, path string, header http.Header,
+		timeoutMs uint64, params map[string]string) (*http.Response, error) {
+		assert.Nil(t, client.CancelListenConfig(param))
+		return http_agent.FakeHttpResponse(200, "content2"), nil
+	})
+
+	client.listenConfigsTask(clientConfigTest, serverConfigsTest, mockHttpAgent, []vo.ConfigParam{param})
+	assert.Equal(t, 0, len(client.localConfigs))
+	assert.Equal(t, 0, client.configInfos.Count())
+	assert.False(t, client.listenerManager.HasListener("", "dataId", "group"))
+}
+
+func TestListenerManager_RemoveListener(t *testing.T) {
+	lm := NewListenerManager(2)
+	lm.AddListener("", vo.ConfigParam{DataId: "a", Group: "group"})
+	lm.AddListener("", vo.ConfigParam{DataId: "b", Group: "group"})
+
+	assert.True(t, lm.RemoveListener("", vo.ConfigParam{DataId: "a", Group: "group"}))
+	assert.False(t, lm.RemoveListener("", vo.ConfigParam{DataId: "a", Group: "group"}))
+	assert.False(t, lm.HasListener("", "a", "group"))
+	assert.Equal(t, 1, len(lm.TaskParams(0)))
+
+	// 空出的位置会被复用
+	taskId, start := lm.AddListener("", vo.ConfigParam{DataId: "c", Group: "group"})
+	assert.Equal(t, 0, taskId)
+	assert.False(t, start)
+
+	lm.RemoveListener("", vo.ConfigParam{DataId: "b", Group: "group"})
+	lm.RemoveListener("", vo.ConfigParam{DataId: "c", Group: "group"})
+	assert.Equal(t, 0, len(lm.TaskParams(0)))
+
+	// 任务已停止，再次监听时需要重新启动
+	_, start = lm.AddListener("", vo.ConfigParam{DataId: "d", Group: "group"})
+	assert.True(t, start)
+}
+
+func TestListenerManager_RemoveEntry(t *testing.T) {
+	lm := NewListenerManager(2)
+	param := vo.ConfigParam{DataId: "a", Group: "group"}
+	_, _, first := lm.addListener("", param)
+	_, _, second := lm.addListener("", param)
+
+	assert.False(t, lm.removeEntry("", param, first))
+	assert.True(t, lm.HasListener("", "a", "group"))
+	assert.Equal(t, 1, len(lm.TaskParams(0)))
+	assert.True(t, lm.removeEntry("", param, second))
+	assert.False(t, lm.HasListener("", "a", "group"))
+}
+
+func TestWatchConfig(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		Return(http_agent.FakeHttpResponse(200, ""), nil)
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+	defer client.Close()
+
+	_, err := client.WatchConfig(context.Background(), vo.ConfigParam{Group: "group"})
+	assert.NotNil(t, err)
+
+	type config struct {
+		Name string `json:"name"`
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := client.WatchConfig(ctx, vo.ConfigParam{DataId: "dataId.json", Group: "group", Target: &config{}})
+	assert.Nil(t, err)
+	// 同一配置上的其它监听不受取消的影响
+	assert.Nil(t, client.ListenConfig(vo.ConfigParam{DataId: "dataId.json", Group: "group"}))
+
+	go client.notifyListeners("", "dataId.json", "group", `{"name":"a"}`, client.listenerManager.TaskParams(0))
+	select {
+	case event := <-events:
+		assert.Equal(t, "dataId.json", event.DataId)
+		assert.Equal(t, `{"name":"a"}`, event.Content)
+		assert.Nil(t, event.Err)
+		assert.Equal(t, "a", event.Value.(*config).Name)
+	case <-time.After(time.Second):
+		t.Fatal("config change event not received")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel should be closed after ctx is cancelled")
+	}
+	assert.True(t, client.listenerManager.HasListener("", "dataId.json", "group"))
+	assert.Equal(t, 1, len(client.listenerManager.TaskParams(0)))
+}
+
+func TestConfigWatcher_Offer(t *testing.T) {
+	w := newConfigWatcher()
+	defer w.close()
+	// channel 已满时不阻塞，暂存期间只保留最新的事件
+	for i := 0; i < cap(w.events)+3; i++ {
+		w.offer(model.ConfigChangeEvent{Content: strconv.Itoa(i)})
+	}
+	for i := 0; i < cap(w.events); i++ {
+		assert.Equal(t, strconv.Itoa(i), (<-w.events).Content)
+	}
+	select {
+	case event := <-w.events:
+		assert.Equal(t, strconv.Itoa(cap(w.events)+2), event.Content)
+	case <-time.After(time.Second):
+		t.Fatal("pending config event not received")
+	}
+}
+
+func TestCancelListenConfig(t *testing.T) {
+	client := cretateConfigClientTest()
+	param := vo.ConfigParam{
+		DataId: "dataId",
+		Group:  "group",
+	}
+	err := client.CancelListenConfig(param)
+	assert.NotNil(t, err)
+
+	client.localConfigs = []vo.ConfigParam{{DataId: "dataId", Group: "group", Content: "content"}}
+	client.configInfos.Set(utils.GetConfigCacheKey("dataId", "group", ""), model.ConfigInfo{DataId: "dataId"})
+	client.failovers.Set(utils.GetConfigCacheKey("dataId", "group", ""), true)
+	client.listenerManager.AddListener("", param)
+	err = client.CancelListenConfig(param)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(client.localConfigs))
+	assert.Equal(t, 0, client.configInfos.Count())
+	assert.Equal(t, 0, client.failovers.Count())
+	assert.False(t, client.listenerManager.HasListener("", "dataId", "group"))
+}
+
+func TestListenerManager_CancelledListenerNotCalled(t *testing.T) {
+	lm := NewListenerManager(2)
+	called := 0
+	lm.AddListener("", vo.ConfigParam{DataId: "a", Group: "group", OnChange: func(namespace, group, dataId, data string) {
+		called++
+	}})
+	params := lm.TaskParams(0)
+	params[0].OnChange("", "group", "a", "content")
+	assert.Equal(t, 1, called)
+
+	lm.RemoveListener("", vo.ConfigParam{DataId: "a", Group: "group"})
+	params[0].OnChange("", "group", "a", "content")
+	assert.Equal(t, 1, called)
+}
+
+func TestConfigClient_Close(t *testing.T) {
+	client := cretateConfigClientTest()
+	client.localConfigs = []vo.ConfigParam{{DataId: "dataId", Group: "group", Content: "content"}}
+	client.listenerManager.AddListener("", configParamTest)
+	client.configInfos.Set("dataId@@group@@", model.ConfigInfo{DataId: "dataId"})
+	client.failovers.Set("dataId@@group@@", true)
+
+	assert.Nil(t, client.Close())
+	assert.Nil(t, client.Close())
+	assert.Equal(t, 0, len(client.localConfigs))
+	assert.Equal(t, 0, client.configInfos.Count())
+	assert.Equal(t, 0, client.failovers.Count())
+	assert.True(t, client.listenerManager.IsClosed())
+
+	err := client.ListenConfig(configParamTest)
+	assert.NotNil(t, err)
+}
+
+func TestConfigClient_CloseCancelsListen(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockHttpAgent := mock.NewMockIHttpAgent(controller)
+	started := make(chan struct{}, 1)
+	cancelled := make(chan struct{})
+	mockHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodPost),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/cs/configs/listener"),
+		gomock.Any(), gomock.Any(), gomock.Any(),
+	).Times(1).DoAndReturn(func(ctx context.Context, method string, path string, header http.Header,
+		timeoutMs uint64, params map[string]string) (*http.Response, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	client := cretateConfigClientHttpTest(mockHttpAgent)
+	assert.Nil(t, client.ListenConfig(vo.ConfigParam{DataId: "dataId", Group: "group", Content: "content"}))
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("listen request not started")
+	}
+	assert.Nil(t, client.Close())
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("listen request should be cancelled after Close")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/uugtv/nacos-sdk-go/common/constant"
 	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/utils"
 )
 
@@ -49,13 +50,17 @@ func (client *NacosClient) SetClientConfig(config constant.ClientConfig) (err er
 		config.UpdateThreadNum = 20
 	}
 
+	if config.PerTaskConfigSize <= 0 {
+		config.PerTaskConfigSize = constant.PER_TASK_CONFIG_SIZE
+	}
+
 	if config.CacheDir == "" {
 		config.CacheDir = utils.GetCurrentPath() + string(os.PathSeparator) + "cache"
 	}
 	if config.LogDir == "" {
 		config.LogDir = utils.GetCurrentPath() + string(os.PathSeparator) + "log"
 	}
-	// log.Printf("[INFO] logDir:<%s>   cacheDir:<%s>", config.LogDir, config.CacheDir)
+	logger.Info("[client.SetClientConfig] client config", logger.F("logDir", config.LogDir), logger.F("cacheDir", config.CacheDir))
 	client.clientConfig = config
 	client.clientConfigValid = true
 
@@ -1,38 +1,55 @@
 package naming_client
 
 import (
+	"context"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	nsema "github.com/toolkits/concurrent/semaphore"
 	"github.com/uugtv/nacos-sdk-go/clients/cache"
 	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
+	"github.com/uugtv/nacos-sdk-go/vo"
 )
 
 type BeatReactor struct {
-	beatMap             cache.ConcurrentMap
-	serviceProxy        NamingProxy
-	clientBeatInterval  int64
-	beatThreadCount     int
-	beatThreadSemaphore *nsema.Semaphore
-	beatRecordMap       cache.ConcurrentMap
+	beatMap            cache.ConcurrentMap
+	serviceProxy       *NamingProxy
+	clientBeatInterval int64
+	beatThreadCount    int
+	scheduler          *beatScheduler
+	// 实例注册时的参数，服务端报告实例不存在时用于重新注册，实例事件回调注册时设置的 OnEvent
+	registerParamMap cache.ConcurrentMap
+	// 实例事件按实例分别排队，同一实例的事件按发生的顺序回调
+	events    *EventDispatcher
+	closeOnce *sync.Once
 }
 
-const Default_Beat_Thread_Num = 20
+const (
+	Default_Beat_Thread_Num        = 20
+	Default_Beat_Failure_Threshold = 3
+)
 
-func NewBeatReactor(serviceProxy NamingProxy, clientBeatInterval int64) BeatReactor {
+func NewBeatReactor(serviceProxy *NamingProxy, clientBeatInterval int64, beatThreadNum int, beatFailureThreshold int) BeatReactor {
 	br := BeatReactor{}
 	if clientBeatInterval <= 0 {
 		clientBeatInterval = 5 * 1000
 	}
+	if beatThreadNum <= 0 {
+		beatThreadNum = Default_Beat_Thread_Num
+	}
 	br.beatMap = cache.NewConcurrentMap()
 	br.serviceProxy = serviceProxy
 	br.clientBeatInterval = clientBeatInterval
-	br.beatThreadCount = Default_Beat_Thread_Num
-	br.beatRecordMap = cache.NewConcurrentMap()
-	br.beatThreadSemaphore = nsema.NewSemaphore(br.beatThreadCount)
+	br.beatThreadCount = beatThreadNum
+	br.registerParamMap = cache.NewConcurrentMap()
+	br.scheduler = newBeatScheduler(br.beatThreadCount, realClock{},
+		time.Duration(clientBeatInterval)*time.Millisecond, beatFailureThreshold, br.sendBeat, br.notify)
+	br.events = NewEventDispatcher(0, 0, constant.DISPATCH_POLICY_DROP_OLDEST)
+	br.closeOnce = &sync.Once{}
 	return br
 }
 
@@ -41,50 +58,175 @@ func buildKey(serviceName string, ip string, port uint64) string {
 }
 
 func (br *BeatReactor) AddBeatInfo(serviceName string, beatInfo model.BeatInfo) {
-	// log.Printf("[INFO] adding beat: <%s> to beat map.\n", utils.ToJsonString(beatInfo))
+	logger.Info("[BeatReactor] adding beat to beat map", logger.F("beat", utils.ToJsonString(beatInfo)))
 	k := buildKey(serviceName, beatInfo.Ip, beatInfo.Port)
 	br.beatMap.Set(k, &beatInfo)
-	go br.sendInstanceBeat(k, &beatInfo)
+	br.scheduler.add(k, &beatInfo)
+}
+
+// AddRegisteredInstance 开始发送实例的心跳，并保存注册参数，服务端报告实例不存在时按该参数重新注册
+func (br *BeatReactor) AddRegisteredInstance(param vo.RegisterInstanceParam, beatInfo model.BeatInfo) {
+	serviceName := utils.GetGroupName(param.ServiceName, param.GroupName)
+	k := buildKey(serviceName, param.Ip, param.Port)
+	// 重新注册时丢弃上一次注册尚未回调的事件，之后的事件回调新设置的 OnEvent
+	if param.OnEvent != nil {
+		onEvent := param.OnEvent
+		br.events.add(k, k, func(task dispatchTask) bool {
+			onEvent(*task.instanceEvent)
+			return true
+		})
+	} else {
+		br.events.remove(k)
+	}
+	br.registerParamMap.Set(k, param)
+	br.AddBeatInfo(serviceName, beatInfo)
+}
+
+// UpdateBeatInfo 实例信息更新后修改心跳信息和保存的注册参数，下一次心跳携带完整的实例信息
+func (br *BeatReactor) UpdateBeatInfo(param vo.UpdateInstanceParam) bool {
+	k := buildKey(utils.GetGroupName(param.ServiceName, param.GroupName), param.Ip, param.Port)
+	updated := br.scheduler.update(k, func(beatInfo *model.BeatInfo) {
+		beatInfo.Weight = param.Weight
+		beatInfo.Metadata = param.Metadata
+		beatInfo.Cluster = param.ClusterName
+		beatInfo.Period = utils.GetDurationWithDefault(param.Metadata, constant.HEART_BEAT_INTERVAL, time.Second*5)
+	})
+	if !updated {
+		return false
+	}
+	logger.Info("[BeatReactor] update beat info", logger.F("instance", k), logger.F("weight", param.Weight),
+		logger.F("clusterName", param.ClusterName), logger.F("metadata", utils.ToJsonString(param.Metadata)))
+	if data, ok := br.registerParamMap.Get(k); ok {
+		registerParam := data.(vo.RegisterInstanceParam)
+		registerParam.Weight = param.Weight
+		if param.Enable != nil {
+			registerParam.Enable = *param.Enable
+		}
+		registerParam.Metadata = param.Metadata
+		registerParam.ClusterName = param.ClusterName
+		br.registerParamMap.Set(k, registerParam)
+	}
+	return true
+}
+
+// enableOf 返回更新实例时的 Enable，没有指定时使用本客户端注册实例时的值，不是本客户端注册的实例默认为 true
+func (br *BeatReactor) enableOf(serviceName string, param vo.UpdateInstanceParam) bool {
+	if param.Enable != nil {
+		return *param.Enable
+	}
+	if data, ok := br.registerParamMap.Get(buildKey(serviceName, param.Ip, param.Port)); ok {
+		return data.(vo.RegisterInstanceParam).Enable
+	}
+	return true
 }
 
 func (br *BeatReactor) RemoveBeatInfo(serviceName string, ip string, port uint64) {
-	// log.Printf("[INFO] remove beat: %s@%s:%d from beat map.\n", serviceName, ip, port)
+	logger.Info("[BeatReactor] remove beat from beat map", logger.F("serviceName", serviceName), logger.F("ip", ip), logger.F("port", port))
 	k := buildKey(serviceName, ip, port)
-	data, exist := br.beatMap.Get(k)
-	if exist {
-		beatInfo := data.(*model.BeatInfo)
-		beatInfo.Stopped = true
-	}
+	br.scheduler.remove(k)
 	br.beatMap.Remove(k)
+	br.registerParamMap.Remove(k)
+	br.events.remove(k)
 }
 
-func (br *BeatReactor) sendInstanceBeat(k string, beatInfo *model.BeatInfo) {
-	for {
-		br.beatThreadSemaphore.Acquire()
-		//如果当前实例注销，则进行停止心跳
-		if beatInfo.Stopped {
-			// log.Printf("[INFO] intance[%s] stop heartBeating\n", k)
-			br.beatThreadSemaphore.Release()
-			return
-		}
+// GetBeatStatus 返回实例心跳的状态，实例没有在发送心跳时返回 false
+func (br *BeatReactor) GetBeatStatus(serviceName string, ip string, port uint64) (model.BeatStatus, bool) {
+	return br.scheduler.status(buildKey(serviceName, ip, port))
+}
 
-		//进行心跳通信
-		beatInterval, err := br.serviceProxy.SendBeat(*beatInfo)
-		if err != nil {
-			// log.Printf("[ERROR]:beat to server return error:%s \n", err.Error())
-			br.beatThreadSemaphore.Release()
-			t := time.NewTimer(beatInfo.Period)
-			<-t.C
-			continue
-		}
-		if beatInterval > 0 {
-			beatInfo.Period = time.Duration(time.Millisecond.Nanoseconds() * beatInterval)
-		}
+// sendBeat 由调度器的发送线程调用，服务端没有该实例时重新注册，之后的第一次心跳携带完整的实例信息
+func (br *BeatReactor) sendBeat(k string, beatInfo model.BeatInfo) (model.BeatResult, error) {
+	beatResult, err := br.serviceProxy.SendBeat(beatInfo)
+	if err != nil {
+		return beatResult, err
+	}
+	if beatResult.Code == constant.RESOURCE_NOT_FOUND_CODE {
+		br.reRegister(k, beatInfo)
+		beatResult.LightBeatEnabled = false
+	}
+	return beatResult, nil
+}
 
-		br.beatRecordMap.Set(k, utils.CurrentMillis())
-		br.beatThreadSemaphore.Release()
+// registerParam 返回实例注册时的参数，没有保存注册参数时按心跳信息构造
+func (br *BeatReactor) registerParam(k string, beatInfo model.BeatInfo) vo.RegisterInstanceParam {
+	if data, ok := br.registerParamMap.Get(k); ok {
+		return data.(vo.RegisterInstanceParam)
+	}
+	param := vo.RegisterInstanceParam{
+		Ip:          beatInfo.Ip,
+		Port:        beatInfo.Port,
+		Weight:      beatInfo.Weight,
+		Enable:      true,
+		Healthy:     true,
+		Metadata:    beatInfo.Metadata,
+		ClusterName: beatInfo.Cluster,
+		ServiceName: beatInfo.ServiceName,
+		GroupName:   constant.DEFAULT_GROUP,
+		Ephemeral:   true,
+	}
+	if names := strings.SplitN(beatInfo.ServiceName, constant.SERVICE_INFO_SPLITER, 2); len(names) == 2 {
+		param.GroupName = names[0]
+		param.ServiceName = names[1]
+	}
+	return param
+}
 
-		t := time.NewTimer(beatInfo.Period)
-		<-t.C
+// reRegister 服务端没有该实例（服务端重启或实例过期）时重新注册，并通知注册时设置的 OnEvent
+func (br *BeatReactor) reRegister(k string, beatInfo model.BeatInfo) {
+	param := br.registerParam(k, beatInfo)
+	serviceName := utils.GetGroupName(param.ServiceName, param.GroupName)
+	logger.Warn("[BeatReactor] instance not found in server, register it again", logger.F("instance", k))
+	_, err := br.serviceProxy.RegisterInstance(context.Background(), serviceName, param.GroupName, buildInstance(param))
+	if err != nil {
+		logger.Error("[BeatReactor] register instance again return error", logger.F("instance", k), logger.Err(err))
 	}
+	br.notify(k, beatInfo, constant.INSTANCE_EVENT_REREGISTERED, err)
+}
+
+// notify 通知注册实例时设置的 OnEvent
+func (br *BeatReactor) notify(k string, beatInfo model.BeatInfo, eventType string, err error) {
+	if eventType == constant.INSTANCE_EVENT_BEAT_FAILED {
+		logger.Error("[BeatReactor] instance beat keeps failing", logger.F("instance", k), logger.Err(err))
+	}
+	param := br.registerParam(k, beatInfo)
+	br.notifyInstanceEvent(k, param.OnEvent, model.InstanceEvent{
+		Type:        eventType,
+		ServiceName: param.ServiceName,
+		GroupName:   param.GroupName,
+		Ip:          param.Ip,
+		Port:        param.Port,
+		ClusterName: param.ClusterName,
+		Err:         err,
+	})
+}
+
+// notifyInstanceEvent 在 dispatcher 的线程中回调注册时设置的 OnEvent，不阻塞心跳的发送，同一实例的事件按发生的顺序回调
+// 实例注销后不再回调
+func (br *BeatReactor) notifyInstanceEvent(k string, onEvent func(event model.InstanceEvent), event model.InstanceEvent) {
+	if onEvent == nil {
+		return
+	}
+	br.events.dispatch(k, dispatchTask{instanceEvent: &event})
+}
+
+// 停止全部心跳，返回关闭前仍在发送心跳的实例
+func (br *BeatReactor) Close() []model.BeatInfo {
+	var beatInfos []model.BeatInfo
+	br.closeOnce.Do(func() {
+		br.scheduler.stop()
+		br.events.Close()
+		for k, v := range br.beatMap.Items() {
+			beatInfo := br.scheduler.snapshot(v.(*model.BeatInfo))
+			beatInfos = append(beatInfos, model.BeatInfo{
+				Ip:          beatInfo.Ip,
+				Port:        beatInfo.Port,
+				Weight:      beatInfo.Weight,
+				ServiceName: beatInfo.ServiceName,
+				Cluster:     beatInfo.Cluster,
+				Metadata:    beatInfo.Metadata,
+			})
+			br.beatMap.Remove(k)
+		}
+	})
+	return beatInfos
 }
@@ -2,14 +2,17 @@ package naming_client
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
+	"github.com/uugtv/nacos-sdk-go/vo"
 )
 
 func TestBeatReactor_AddBeatInfo(t *testing.T) {
-	br := NewBeatReactor(NamingProxy{}, 5000)
+	br := NewBeatReactor(&NamingProxy{}, 5000, 0, 0)
 	serviceName := "Test"
 	groupName := "public"
 	beatInfo := model.BeatInfo{
@@ -24,11 +27,11 @@ func TestBeatReactor_AddBeatInfo(t *testing.T) {
 	key := buildKey(utils.GetGroupName(serviceName, groupName), beatInfo.Ip, beatInfo.Port)
 	result, ok := br.beatMap.Get(key)
 	assert.Equal(t, ok, true, "key should exists!")
-	assert.ObjectsAreEqual(result.(model.BeatInfo), beatInfo)
+	assert.ObjectsAreEqual(*result.(*model.BeatInfo), beatInfo)
 }
 
 func TestBeatReactor_RemoveBeatInfo(t *testing.T) {
-	br := NewBeatReactor(NamingProxy{}, 5000)
+	br := NewBeatReactor(&NamingProxy{}, 5000, 0, 0)
 	serviceName := "Test"
 	groupName := "public"
 	beatInfo1 := model.BeatInfo{
@@ -54,6 +57,62 @@ func TestBeatReactor_RemoveBeatInfo(t *testing.T) {
 	result, ok := br.beatMap.Get(key)
 	assert.Equal(t, br.beatMap.Count(), 1, "beatinfo map length should be 1")
 	assert.Equal(t, ok, true, "key should exists!")
-	assert.ObjectsAreEqual(result.(model.BeatInfo), beatInfo2)
+	assert.ObjectsAreEqual(*result.(*model.BeatInfo), beatInfo2)
 
 }
+
+func TestBeatReactor_Close(t *testing.T) {
+	br := NewBeatReactor(&NamingProxy{}, 5000, 0, 0)
+	beatInfo := model.BeatInfo{
+		Ip:          "127.0.0.1",
+		Port:        8080,
+		ServiceName: utils.GetGroupName("Test", "public"),
+		Period:      time.Hour,
+	}
+	br.AddBeatInfo(beatInfo.ServiceName, beatInfo)
+
+	beatInfos := br.Close()
+	assert.Equal(t, 1, len(beatInfos))
+	assert.Equal(t, "127.0.0.1", beatInfos[0].Ip)
+	assert.Equal(t, 0, br.beatMap.Count())
+	assert.Equal(t, 0, len(br.Close()))
+}
+
+func TestBeatReactor_NotifyOrdered(t *testing.T) {
+	br := NewBeatReactor(&NamingProxy{}, 5000, 0, 0)
+	defer br.Close()
+	events := make(chan string, 100)
+	param := vo.RegisterInstanceParam{ServiceName: "Test", GroupName: "public", Ip: "127.0.0.1", Port: 8080,
+		OnEvent: func(event model.InstanceEvent) {
+			events <- event.Type
+		}}
+	br.AddRegisteredInstance(param, model.BeatInfo{Ip: param.Ip, Port: param.Port, ServiceName: utils.GetGroupName("Test", "public"), Period: time.Hour})
+	k := buildKey(utils.GetGroupName("Test", "public"), param.Ip, param.Port)
+
+	var expected []string
+	for i := 0; i < 50; i++ {
+		eventType := constant.INSTANCE_EVENT_BEAT_FAILED
+		if i%2 == 1 {
+			eventType = constant.INSTANCE_EVENT_RECOVERED
+		}
+		expected = append(expected, eventType)
+		br.notify(k, model.BeatInfo{}, eventType, nil)
+	}
+	var received []string
+	for range expected {
+		select {
+		case eventType := <-events:
+			received = append(received, eventType)
+		case <-time.After(time.Second):
+			t.Fatalf("expect %d events, received %d", len(expected), len(received))
+		}
+	}
+	assert.Equal(t, expected, received)
+
+	// 注销后不再回调
+	br.RemoveBeatInfo(utils.GetGroupName("Test", "public"), param.Ip, param.Port)
+	br.notify(k, model.BeatInfo{}, constant.INSTANCE_EVENT_BEAT_FAILED, nil)
+	assert.True(t, br.events.waitIdle(time.Second))
+	assert.Equal(t, 0, len(events))
+	assert.Equal(t, 0, br.events.Metrics().Subscribers)
+}
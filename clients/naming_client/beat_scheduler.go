@@ -0,0 +1,188 @@
+package naming_client
+
+import (
+	"time"
+
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+// 下次心跳时间在 beat_merge_window 之内的实例和已到期的实例一起发送，减少调度线程被唤醒的次数
+const beat_merge_window = 100 * time.Millisecond
+
+type beatTask struct {
+	timerTask
+	beatInfo    *model.BeatInfo
+	lastSuccess time.Time
+	failures    int
+	// 发送心跳之后心跳信息被修改过，服务端的响应不能让新的信息以轻量心跳发送
+	modified bool
+}
+
+// beatScheduler 按每个实例各自的下次心跳时间调度，到期的心跳交给固定数量的线程发送
+// 心跳信息只在持有 mutex 时读写，发送时使用副本
+type beatScheduler struct {
+	*timerScheduler
+	send          func(key string, info model.BeatInfo) (model.BeatResult, error)
+	defaultPeriod time.Duration
+	// 连续失败 failureThreshold 次时回调 notify(INSTANCE_EVENT_BEAT_FAILED)，之后第一次成功时回调 notify(INSTANCE_EVENT_RECOVERED)
+	failureThreshold int
+	notify           func(key string, info model.BeatInfo, eventType string, err error)
+	tasks            map[string]*beatTask
+}
+
+func newBeatScheduler(threadNum int, clock schedulerClock, defaultPeriod time.Duration, failureThreshold int,
+	send func(key string, info model.BeatInfo) (model.BeatResult, error),
+	notify func(key string, info model.BeatInfo, eventType string, err error)) *beatScheduler {
+	if threadNum <= 0 {
+		threadNum = Default_Beat_Thread_Num
+	}
+	if failureThreshold <= 0 {
+		failureThreshold = Default_Beat_Failure_Threshold
+	}
+	s := &beatScheduler{
+		send:             send,
+		defaultPeriod:    defaultPeriod,
+		failureThreshold: failureThreshold,
+		notify:           notify,
+		tasks:            map[string]*beatTask{},
+	}
+	s.timerScheduler = newTimerScheduler(threadNum, clock, beat_merge_window, s.execute)
+	return s
+}
+
+// add 立即发送一次心跳，之后按心跳周期发送，同一实例已存在时替换心跳信息
+func (s *beatScheduler) add(key string, beatInfo *model.BeatInfo) {
+	s.mutex.Lock()
+	if old, ok := s.tasks[key]; ok {
+		old.beatInfo.Stopped = true
+		s.removeLocked(old)
+	}
+	task := &beatTask{timerTask: newTimerTask(key), beatInfo: beatInfo}
+	task.due = s.clock.Now()
+	s.tasks[key] = task
+	s.pushLocked(task)
+	s.mutex.Unlock()
+	s.wake()
+}
+
+// remove 停止实例的心跳，正在发送的心跳完成后不再调度
+func (s *beatScheduler) remove(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	task, ok := s.tasks[key]
+	if !ok {
+		return
+	}
+	task.beatInfo.Stopped = true
+	delete(s.tasks, key)
+	s.removeLocked(task)
+}
+
+// update 修改实例的心跳信息，下一次心跳携带完整的实例信息，实例没有在发送心跳时返回 false
+// 心跳周期被修改时，下次心跳时间按新的周期从上一次心跳开始计算
+func (s *beatScheduler) update(key string, modify func(beatInfo *model.BeatInfo)) bool {
+	s.mutex.Lock()
+	task, ok := s.tasks[key]
+	if !ok {
+		s.mutex.Unlock()
+		return false
+	}
+	period := s.periodLocked(task)
+	modify(task.beatInfo)
+	task.beatInfo.LightBeatEnabled = false
+	task.modified = true
+	rescheduled := false
+	if newPeriod := s.periodLocked(task); newPeriod != period && task.index >= 0 {
+		task.due = task.due.Add(newPeriod - period)
+		s.pushLocked(task)
+		rescheduled = true
+	}
+	s.mutex.Unlock()
+	if rescheduled {
+		s.wake()
+	}
+	return true
+}
+
+// snapshot 返回心跳信息的副本
+func (s *beatScheduler) snapshot(beatInfo *model.BeatInfo) model.BeatInfo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return *beatInfo
+}
+
+// status 返回实例最近一次心跳成功的时间、连续失败次数和当前的心跳周期
+func (s *beatScheduler) status(key string) (model.BeatStatus, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	task, ok := s.tasks[key]
+	if !ok {
+		return model.BeatStatus{}, false
+	}
+	return model.BeatStatus{
+		LastSuccess:         task.lastSuccess,
+		ConsecutiveFailures: task.failures,
+		Period:              s.periodLocked(task),
+	}, true
+}
+
+func (s *beatScheduler) periodLocked(task *beatTask) time.Duration {
+	if task.beatInfo.Period > 0 {
+		return task.beatInfo.Period
+	}
+	return s.defaultPeriod
+}
+
+func (s *beatScheduler) execute(task scheduledTask) {
+	beatTask := task.(*beatTask)
+	s.mutex.Lock()
+	beatInfo := *beatTask.beatInfo
+	beatTask.modified = false
+	s.mutex.Unlock()
+	beatResult, err := s.send(beatTask.key, beatInfo)
+	s.finish(beatTask, beatResult, err)
+}
+
+// finish 按服务端的响应更新心跳周期和是否使用轻量心跳，记录成功或连续失败，并调度下一次心跳
+func (s *beatScheduler) finish(task *beatTask, beatResult model.BeatResult, err error) {
+	s.mutex.Lock()
+	task.running = false
+	if s.tasks[task.key] != task {
+		// 发送期间实例已注销
+		s.mutex.Unlock()
+		logger.Info("[BeatReactor] instance stop heartBeating", logger.F("instance", task.key))
+		return
+	}
+	eventType := ""
+	if err != nil {
+		task.failures++
+		logger.Error("[BeatReactor] beat to server return error", logger.F("instance", task.key),
+			logger.F("failures", task.failures), logger.Err(err))
+		if task.failures == s.failureThreshold {
+			eventType = constant.INSTANCE_EVENT_BEAT_FAILED
+		}
+	} else {
+		if task.failures >= s.failureThreshold {
+			eventType = constant.INSTANCE_EVENT_RECOVERED
+		}
+		task.failures = 0
+		task.lastSuccess = s.clock.Now()
+		if beatResult.ClientBeatInterval > 0 {
+			task.beatInfo.Period = time.Duration(beatResult.ClientBeatInterval) * time.Millisecond
+		}
+		if !task.modified {
+			task.beatInfo.LightBeatEnabled = beatResult.LightBeatEnabled
+		}
+	}
+	task.due = s.clock.Now().Add(s.periodLocked(task))
+	s.pushLocked(task)
+	beatInfo := *task.beatInfo
+	s.mutex.Unlock()
+	s.wake()
+
+	if eventType != "" && s.notify != nil {
+		s.notify(task.key, beatInfo, eventType, err)
+	}
+}
@@ -0,0 +1,325 @@
+package naming_client
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+// beatRecorder 记录发送的心跳，并返回预设的响应
+type beatRecorder struct {
+	mutex      sync.Mutex
+	beats      chan string
+	beatInfos  []model.BeatInfo
+	beatResult model.BeatResult
+	err        error
+}
+
+func newBeatRecorder() *beatRecorder {
+	return &beatRecorder{beats: make(chan string, 10)}
+}
+
+func (r *beatRecorder) send(key string, info model.BeatInfo) (model.BeatResult, error) {
+	r.mutex.Lock()
+	r.beatInfos = append(r.beatInfos, info)
+	beatResult, err := r.beatResult, r.err
+	r.mutex.Unlock()
+	r.beats <- key
+	return beatResult, err
+}
+
+func (r *beatRecorder) set(beatResult model.BeatResult, err error) {
+	r.mutex.Lock()
+	r.beatResult = beatResult
+	r.err = err
+	r.mutex.Unlock()
+}
+
+func (r *beatRecorder) lastBeatInfo() model.BeatInfo {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.beatInfos[len(r.beatInfos)-1]
+}
+
+// waitBeatsScheduled 等待没有正在发送的心跳，保证之后推进时钟时下次心跳时间已经确定
+func waitBeatsScheduled(t *testing.T, s *beatScheduler) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mutex.Lock()
+		running := false
+		for _, task := range s.tasks {
+			running = running || task.running
+		}
+		s.mutex.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("beat is still running")
+}
+
+func expectBeats(t *testing.T, beats chan string, expected ...string) {
+	var received []string
+	for range expected {
+		select {
+		case key := <-beats:
+			received = append(received, key)
+		case <-time.After(time.Second):
+			t.Fatalf("expect beats %v, received %v", expected, received)
+		}
+	}
+	assert.ElementsMatch(t, expected, received)
+}
+
+func TestBeatScheduler_Period(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	s := newBeatScheduler(1, clock, 5*time.Second, 0, recorder.send, nil)
+	defer s.stop()
+
+	s.add("A", &model.BeatInfo{Period: 2 * time.Second})
+	s.add("B", &model.BeatInfo{})
+	// 注册后立即发送一次心跳
+	expectBeats(t, recorder.beats, "A", "B")
+	waitBeatsScheduled(t, s)
+	expectNoRefresh(t, recorder.beats)
+
+	clock.Advance(2 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	clock.Advance(2 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	// 没有设置心跳周期的实例使用默认周期
+	clock.Advance(time.Second)
+	expectBeats(t, recorder.beats, "B")
+}
+
+func TestBeatScheduler_MergeDueBeats(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	s := newBeatScheduler(2, clock, 5*time.Second, 0, recorder.send, nil)
+	defer s.stop()
+
+	s.add("A", &model.BeatInfo{})
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	clock.Advance(beat_merge_window / 2)
+	s.add("B", &model.BeatInfo{})
+	expectBeats(t, recorder.beats, "B")
+	waitBeatsScheduled(t, s)
+
+	// B 的下次心跳在 merge window 之内，和 A 一起发送
+	clock.Advance(5*time.Second - beat_merge_window/2)
+	expectBeats(t, recorder.beats, "A", "B")
+	waitBeatsScheduled(t, s)
+	expectNoRefresh(t, recorder.beats)
+}
+
+func TestBeatScheduler_ClientBeatInterval(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	recorder.set(model.BeatResult{ClientBeatInterval: 10 * 1000}, nil)
+	s := newBeatScheduler(1, clock, 5*time.Second, 0, recorder.send, nil)
+	defer s.stop()
+
+	beatInfo := &model.BeatInfo{Period: 5 * time.Second}
+	s.add("A", beatInfo)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	assert.Equal(t, 10*time.Second, s.snapshot(beatInfo).Period)
+
+	clock.Advance(5 * time.Second)
+	expectNoRefresh(t, recorder.beats)
+	clock.Advance(5 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+
+	// 发送失败时按当前周期重试，周期不变
+	recorder.set(model.BeatResult{}, errors.New("connection refused"))
+	clock.Advance(10 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	assert.Equal(t, 10*time.Second, s.snapshot(beatInfo).Period)
+	clock.Advance(10 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+}
+
+func TestBeatScheduler_LightBeat(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	recorder.set(model.BeatResult{LightBeatEnabled: true}, nil)
+	s := newBeatScheduler(1, clock, 5*time.Second, 0, recorder.send, nil)
+	defer s.stop()
+
+	beatInfo := &model.BeatInfo{}
+	s.add("A", beatInfo)
+	expectBeats(t, recorder.beats, "A")
+	assert.False(t, recorder.lastBeatInfo().LightBeatEnabled)
+	waitBeatsScheduled(t, s)
+
+	// 服务端允许后发送轻量心跳
+	clock.Advance(5 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	assert.True(t, recorder.lastBeatInfo().LightBeatEnabled)
+	waitBeatsScheduled(t, s)
+
+	// 服务端不再允许时恢复发送完整心跳
+	recorder.set(model.BeatResult{}, nil)
+	clock.Advance(5 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	clock.Advance(5 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	assert.False(t, recorder.lastBeatInfo().LightBeatEnabled)
+}
+
+func TestBeatScheduler_StatusAndFailureHook(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	events := make(chan string, 10)
+	s := newBeatScheduler(1, clock, 5*time.Second, 2, recorder.send,
+		func(key string, info model.BeatInfo, eventType string, err error) {
+			events <- key + ":" + eventType
+		})
+	defer s.stop()
+
+	_, ok := s.status("A")
+	assert.False(t, ok)
+	s.add("A", &model.BeatInfo{Period: 2 * time.Second})
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	status, ok := s.status("A")
+	assert.True(t, ok)
+	assert.Equal(t, model.BeatStatus{LastSuccess: time.Unix(0, 0), Period: 2 * time.Second}, status)
+
+	// 连续失败达到阈值时回调一次
+	recorder.set(model.BeatResult{}, errors.New("connection refused"))
+	for i := 1; i <= 3; i++ {
+		clock.Advance(2 * time.Second)
+		expectBeats(t, recorder.beats, "A")
+		waitBeatsScheduled(t, s)
+		status, _ = s.status("A")
+		assert.Equal(t, i, status.ConsecutiveFailures)
+		assert.Equal(t, time.Unix(0, 0), status.LastSuccess)
+	}
+	expectBeats(t, events, "A:"+constant.INSTANCE_EVENT_BEAT_FAILED)
+	expectNoRefresh(t, events)
+
+	// 恢复后回调并清零连续失败次数
+	recorder.set(model.BeatResult{}, nil)
+	clock.Advance(2 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	expectBeats(t, events, "A:"+constant.INSTANCE_EVENT_RECOVERED)
+	status, _ = s.status("A")
+	assert.Equal(t, model.BeatStatus{LastSuccess: time.Unix(8, 0), Period: 2 * time.Second}, status)
+}
+
+func TestBeatScheduler_BoundedWorkers(t *testing.T) {
+	clock := newFakeClock()
+	release := make(chan struct{})
+	var running, maxRunning, total int32
+	s := newBeatScheduler(2, clock, 5*time.Second, 0, func(key string, info model.BeatInfo) (model.BeatResult, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&total, 1)
+		return model.BeatResult{}, nil
+	}, nil)
+	defer s.stop()
+
+	for _, key := range []string{"A", "B", "C", "D", "E"} {
+		s.add(key, &model.BeatInfo{})
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&running) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&running))
+
+	close(release)
+	deadline = time.Now().Add(time.Second)
+	for atomic.LoadInt32(&total) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&total))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestBeatScheduler_Remove(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	s := newBeatScheduler(1, clock, 5*time.Second, 0, recorder.send, nil)
+	defer s.stop()
+
+	beatInfo := &model.BeatInfo{}
+	s.add("A", beatInfo)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+
+	s.remove("A")
+	assert.True(t, s.snapshot(beatInfo).Stopped)
+	clock.Advance(time.Minute)
+	expectNoRefresh(t, recorder.beats)
+}
+
+func TestBeatScheduler_UpdateSendsFullBeat(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	recorder.set(model.BeatResult{LightBeatEnabled: true}, nil)
+	s := newBeatScheduler(1, clock, 5*time.Second, 0, recorder.send, nil)
+	defer s.stop()
+
+	beatInfo := &model.BeatInfo{Weight: 1}
+	s.add("A", beatInfo)
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	assert.True(t, s.snapshot(beatInfo).LightBeatEnabled)
+
+	assert.True(t, s.update("A", func(beatInfo *model.BeatInfo) {
+		beatInfo.Weight = 2
+	}))
+	assert.False(t, s.update("B", func(beatInfo *model.BeatInfo) {}))
+	clock.Advance(5 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	assert.False(t, recorder.lastBeatInfo().LightBeatEnabled)
+	assert.Equal(t, float64(2), recorder.lastBeatInfo().Weight)
+	waitBeatsScheduled(t, s)
+	clock.Advance(5 * time.Second)
+	expectBeats(t, recorder.beats, "A")
+	assert.True(t, recorder.lastBeatInfo().LightBeatEnabled)
+}
+
+func TestBeatScheduler_UpdatePeriod(t *testing.T) {
+	clock := newFakeClock()
+	recorder := newBeatRecorder()
+	s := newBeatScheduler(1, clock, 5*time.Second, 0, recorder.send, nil)
+	defer s.stop()
+
+	s.add("A", &model.BeatInfo{Period: 10 * time.Second})
+	expectBeats(t, recorder.beats, "A")
+	waitBeatsScheduled(t, s)
+	clock.Advance(time.Second)
+
+	// 下次心跳按新的周期从上一次心跳开始计算
+	assert.True(t, s.update("A", func(beatInfo *model.BeatInfo) {
+		beatInfo.Period = 2 * time.Second
+	}))
+	expectNoRefresh(t, recorder.beats)
+	clock.Advance(time.Second)
+	expectBeats(t, recorder.beats, "A")
+}
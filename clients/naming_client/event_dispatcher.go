@@ -0,0 +1,306 @@
+package naming_client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+const (
+	Default_Callback_Thread_Num = 4
+	Default_Callback_Queue_Size = 64
+
+	// 每个订阅者一次最多连续处理的通知数，超过后让出执行线程，避免一个订阅者占满线程池
+	dispatch_batch_size = 16
+	// 订阅者暂时无法接收通知时，等待该时间后重新投递
+	dispatch_retry_interval = 10 * time.Millisecond
+)
+
+// dispatchTask 一次服务变更通知，保存变更前后的服务，投递时再计算实例变化，合并时保留最早的 oldService
+type dispatchTask struct {
+	oldService *model.Service
+	service    *model.Service
+	// 本客户端注册的实例的事件，不为空时 oldService 和 service 为空
+	instanceEvent *model.InstanceEvent
+}
+
+// subscriberQueue 单个订阅者的通知队列，同一订阅者的通知按顺序串行投递
+// deliver 返回 false 表示订阅者暂时无法接收（例如 channel 已满），通知留在队首稍后重试，期间新的通知按溢出策略处理
+type subscriberQueue struct {
+	serviceKey string
+	deliver    func(task dispatchTask) bool
+	mutex      sync.Mutex
+	tasks      []dispatchTask
+	scheduled  bool
+	removed    bool
+	// drop_newest 丢弃通知后，下一个入队的通知从被丢弃的第一个通知的 oldService 开始计算实例变化
+	skipped    bool
+	skippedOld *model.Service
+}
+
+// EventDispatcher 在固定数量的线程中异步投递订阅通知，推送接收和服务更新线程不会被回调阻塞
+// 订阅者的队列满时按策略处理：drop_oldest 丢弃最早的通知，drop_newest 丢弃新的通知，coalesce 将新通知合并到队尾
+type EventDispatcher struct {
+	// 原子操作的计数放在结构体开头，保证 32 位平台上 8 字节对齐
+	pending     int64
+	dropped     uint64
+	coalesced   uint64
+	panics      uint64
+	queueSize   int
+	policy      string
+	mutex       sync.Mutex
+	cond        *sync.Cond
+	ready       []*subscriberQueue
+	closed      bool
+	subscribers sync.Map
+}
+
+func NewEventDispatcher(threadNum int, queueSize int, policy string) *EventDispatcher {
+	if threadNum <= 0 {
+		threadNum = Default_Callback_Thread_Num
+	}
+	if queueSize <= 0 {
+		queueSize = Default_Callback_Queue_Size
+	}
+	if policy == "" {
+		policy = constant.DISPATCH_POLICY_COALESCE
+	}
+	d := &EventDispatcher{queueSize: queueSize, policy: policy}
+	d.cond = sync.NewCond(&d.mutex)
+	for i := 0; i < threadNum; i++ {
+		go d.work()
+	}
+	return d
+}
+
+func validateOverflowPolicy(policy string) error {
+	switch policy {
+	case "", constant.DISPATCH_POLICY_COALESCE, constant.DISPATCH_POLICY_DROP_OLDEST, constant.DISPATCH_POLICY_DROP_NEWEST:
+		return nil
+	}
+	return errors.New("[client.Subscribe] unsupported callback overflow policy:" + policy)
+}
+
+// add 为订阅者 id 创建队列，之后的通知通过 deliver 投递；id 已有队列时替换，旧队列中尚未投递的通知被丢弃
+func (d *EventDispatcher) add(id interface{}, serviceKey string, deliver func(task dispatchTask) bool) {
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return
+	}
+	old, ok := d.subscribers.Load(id)
+	d.subscribers.Store(id, &subscriberQueue{serviceKey: serviceKey, deliver: deliver})
+	d.mutex.Unlock()
+	if ok {
+		d.discard(old.(*subscriberQueue))
+	}
+}
+
+// dispatch 将通知放入订阅者 id 的队列，订阅者没有通过 add 创建队列或已被移除时丢弃通知
+// 被丢弃的通知合并到相邻的通知中，保留最早的 oldService 和最新的 service，订阅者累计的实例变化不会缺失
+func (d *EventDispatcher) dispatch(id interface{}, task dispatchTask) {
+	if d.isClosed() {
+		return
+	}
+	value, ok := d.subscribers.Load(id)
+	if !ok {
+		return
+	}
+	q := value.(*subscriberQueue)
+
+	q.mutex.Lock()
+	if q.removed {
+		q.mutex.Unlock()
+		return
+	}
+	if len(q.tasks) >= d.queueSize {
+		switch d.policy {
+		case constant.DISPATCH_POLICY_DROP_NEWEST:
+			if !q.skipped {
+				q.skipped = true
+				q.skippedOld = task.oldService
+			}
+			q.mutex.Unlock()
+			atomic.AddUint64(&d.dropped, 1)
+			logger.Warn("[EventDispatcher] subscriber queue is full, drop the newest notification", logger.F("service", q.serviceKey))
+			return
+		case constant.DISPATCH_POLICY_DROP_OLDEST:
+			oldest := q.tasks[0]
+			q.tasks = q.tasks[1:]
+			if len(q.tasks) > 0 {
+				q.tasks[0].oldService = oldest.oldService
+			} else {
+				task.oldService = oldest.oldService
+			}
+			atomic.AddInt64(&d.pending, -1)
+			atomic.AddUint64(&d.dropped, 1)
+			logger.Warn("[EventDispatcher] subscriber queue is full, drop the oldest notification", logger.F("service", q.serviceKey))
+		default:
+			q.tasks[len(q.tasks)-1].service = task.service
+			q.mutex.Unlock()
+			atomic.AddUint64(&d.coalesced, 1)
+			return
+		}
+	}
+	if q.skipped {
+		task.oldService = q.skippedOld
+		q.skipped = false
+		q.skippedOld = nil
+	}
+	q.tasks = append(q.tasks, task)
+	atomic.AddInt64(&d.pending, 1)
+	schedule := !q.scheduled
+	q.scheduled = true
+	q.mutex.Unlock()
+
+	if schedule {
+		d.schedule(q)
+	}
+}
+
+// remove 移除订阅者，尚未投递的通知被丢弃，之后的通知不再创建队列
+func (d *EventDispatcher) remove(id interface{}) {
+	d.mutex.Lock()
+	value, ok := d.subscribers.Load(id)
+	d.subscribers.Delete(id)
+	d.mutex.Unlock()
+	if ok {
+		d.discard(value.(*subscriberQueue))
+	}
+}
+
+func (d *EventDispatcher) discard(q *subscriberQueue) {
+	q.mutex.Lock()
+	q.removed = true
+	atomic.AddInt64(&d.pending, -int64(len(q.tasks)))
+	q.tasks = nil
+	q.mutex.Unlock()
+}
+
+func (d *EventDispatcher) schedule(q *subscriberQueue) {
+	d.mutex.Lock()
+	d.ready = append(d.ready, q)
+	d.mutex.Unlock()
+	d.cond.Signal()
+}
+
+func (d *EventDispatcher) work() {
+	for {
+		d.mutex.Lock()
+		for len(d.ready) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if d.closed {
+			d.mutex.Unlock()
+			return
+		}
+		q := d.ready[0]
+		d.ready = d.ready[1:]
+		d.mutex.Unlock()
+		d.run(q)
+	}
+}
+
+func (d *EventDispatcher) run(q *subscriberQueue) {
+	for i := 0; i < dispatch_batch_size; i++ {
+		q.mutex.Lock()
+		if len(q.tasks) == 0 || q.removed {
+			q.scheduled = false
+			q.mutex.Unlock()
+			return
+		}
+		task := q.tasks[0]
+		q.tasks = q.tasks[1:]
+		q.mutex.Unlock()
+
+		if !d.deliver(q, task) {
+			d.retry(q, task)
+			return
+		}
+		atomic.AddInt64(&d.pending, -1)
+	}
+	d.schedule(q)
+}
+
+// retry 将未被接收的通知放回队首，稍后重新调度；等待期间队列保持已调度状态，不会被重复调度
+func (d *EventDispatcher) retry(q *subscriberQueue, task dispatchTask) {
+	q.mutex.Lock()
+	if q.removed {
+		q.mutex.Unlock()
+		atomic.AddInt64(&d.pending, -1)
+		return
+	}
+	q.tasks = append([]dispatchTask{task}, q.tasks...)
+	q.mutex.Unlock()
+	time.AfterFunc(dispatch_retry_interval, func() {
+		if !d.isClosed() {
+			d.schedule(q)
+		}
+	})
+}
+
+func (d *EventDispatcher) deliver(q *subscriberQueue, task dispatchTask) (delivered bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			delivered = true
+			atomic.AddUint64(&d.panics, 1)
+			logger.Error("[EventDispatcher] subscriber callback panic", logger.F("service", q.serviceKey),
+				logger.F("panic", fmt.Sprint(r)))
+		}
+	}()
+	return q.deliver(task)
+}
+
+// Metrics 返回订阅者数量、各服务待投递的通知数以及丢弃、合并和回调 panic 的次数
+func (d *EventDispatcher) Metrics() model.DispatcherMetrics {
+	metrics := model.DispatcherMetrics{QueueDepths: map[string]int{}}
+	d.subscribers.Range(func(key, value interface{}) bool {
+		q := value.(*subscriberQueue)
+		q.mutex.Lock()
+		depth := len(q.tasks)
+		q.mutex.Unlock()
+		metrics.Subscribers++
+		metrics.QueueDepth += depth
+		metrics.QueueDepths[q.serviceKey] += depth
+		if depth > metrics.MaxQueueDepth {
+			metrics.MaxQueueDepth = depth
+		}
+		return true
+	})
+	metrics.Dropped = atomic.LoadUint64(&d.dropped)
+	metrics.Coalesced = atomic.LoadUint64(&d.coalesced)
+	metrics.Panics = atomic.LoadUint64(&d.panics)
+	return metrics
+}
+
+// waitIdle 等待已入队的通知全部投递完成，超时返回 false
+func (d *EventDispatcher) waitIdle(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&d.pending) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+func (d *EventDispatcher) isClosed() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.closed
+}
+
+// Close 停止投递线程，尚未投递的通知被丢弃，之后不再接受新的通知
+func (d *EventDispatcher) Close() {
+	d.mutex.Lock()
+	d.closed = true
+	d.mutex.Unlock()
+	d.cond.Broadcast()
+}
@@ -0,0 +1,288 @@
+package naming_client
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+func dispatcherTestService(version int) *model.Service {
+	return &model.Service{Name: "public@@Test", Clusters: "default", Checksum: strconv.Itoa(version)}
+}
+
+// blockedSubscriber 第一次投递时阻塞，直到 release 被关闭，用于模拟处理缓慢的订阅者
+type blockedSubscriber struct {
+	mutex    sync.Mutex
+	started  chan struct{}
+	release  chan struct{}
+	once     sync.Once
+	received []dispatchTask
+}
+
+func newBlockedSubscriber() *blockedSubscriber {
+	return &blockedSubscriber{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (s *blockedSubscriber) deliver(task dispatchTask) bool {
+	s.once.Do(func() {
+		close(s.started)
+		<-s.release
+	})
+	s.mutex.Lock()
+	s.received = append(s.received, task)
+	s.mutex.Unlock()
+	return true
+}
+
+func (s *blockedSubscriber) checksums() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var checksums []string
+	for _, task := range s.received {
+		checksums = append(checksums, task.service.Checksum)
+	}
+	return checksums
+}
+
+func TestEventDispatcher_Ordered(t *testing.T) {
+	d := NewEventDispatcher(4, 1000, "")
+	defer d.Close()
+	subscribers := make([]*blockedSubscriber, 3)
+	for i := range subscribers {
+		subscribers[i] = newBlockedSubscriber()
+		close(subscribers[i].release)
+		d.add(subscribers[i], "public@@Test@@default", subscribers[i].deliver)
+	}
+	for version := 0; version < 100; version++ {
+		for _, s := range subscribers {
+			d.dispatch(s, dispatchTask{service: dispatcherTestService(version)})
+		}
+	}
+	assert.True(t, d.waitIdle(time.Second))
+	for _, s := range subscribers {
+		checksums := s.checksums()
+		assert.Equal(t, 100, len(checksums))
+		for version, checksum := range checksums {
+			assert.Equal(t, strconv.Itoa(version), checksum)
+		}
+	}
+}
+
+func TestEventDispatcher_SlowSubscriberIsolated(t *testing.T) {
+	d := NewEventDispatcher(2, 0, "")
+	defer d.Close()
+	slow := newBlockedSubscriber()
+	fast := newBlockedSubscriber()
+	close(fast.release)
+
+	d.add(slow, "slow", slow.deliver)
+	d.add(fast, "fast", fast.deliver)
+	d.dispatch(slow, dispatchTask{service: dispatcherTestService(0)})
+	<-slow.started
+	d.dispatch(fast, dispatchTask{service: dispatcherTestService(0)})
+
+	deadline := time.Now().Add(time.Second)
+	for len(fast.checksums()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"0"}, fast.checksums())
+	close(slow.release)
+	assert.True(t, d.waitIdle(time.Second))
+}
+
+func TestEventDispatcher_RecoverPanic(t *testing.T) {
+	d := NewEventDispatcher(1, 0, "")
+	defer d.Close()
+	var delivered []string
+	deliver := func(task dispatchTask) bool {
+		if task.service.Checksum == "1" {
+			panic("callback failed")
+		}
+		delivered = append(delivered, task.service.Checksum)
+		return true
+	}
+	d.add(&deliver, "public@@Test@@default", deliver)
+	for version := 0; version < 3; version++ {
+		d.dispatch(&deliver, dispatchTask{service: dispatcherTestService(version)})
+	}
+	assert.True(t, d.waitIdle(time.Second))
+	assert.Equal(t, []string{"0", "2"}, delivered)
+	assert.Equal(t, uint64(1), d.Metrics().Panics)
+}
+
+func TestEventDispatcher_Retry(t *testing.T) {
+	d := NewEventDispatcher(1, 0, "")
+	defer d.Close()
+	var mutex sync.Mutex
+	accept := false
+	var delivered []string
+	full := func(task dispatchTask) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !accept {
+			return false
+		}
+		delivered = append(delivered, task.service.Checksum)
+		return true
+	}
+	d.add(&full, "full", full)
+	for version := 0; version < 3; version++ {
+		d.dispatch(&full, dispatchTask{service: dispatcherTestService(version)})
+	}
+	// 订阅者暂时无法接收时不占用执行线程，其它订阅者照常收到通知
+	other := newBlockedSubscriber()
+	close(other.release)
+	d.add(other, "other", other.deliver)
+	d.dispatch(other, dispatchTask{service: dispatcherTestService(0)})
+	deadline := time.Now().Add(time.Second)
+	for len(other.checksums()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"0"}, other.checksums())
+
+	mutex.Lock()
+	accept = true
+	mutex.Unlock()
+	assert.True(t, d.waitIdle(time.Second))
+	assert.Equal(t, []string{"0", "1", "2"}, delivered)
+}
+
+func TestEventDispatcher_OverflowPolicy(t *testing.T) {
+	tests := []struct {
+		policy    string
+		expected  []string
+		dropped   uint64
+		coalesced uint64
+	}{
+		{policy: constant.DISPATCH_POLICY_DROP_NEWEST, expected: []string{"0", "1", "2"}, dropped: 2},
+		{policy: constant.DISPATCH_POLICY_DROP_OLDEST, expected: []string{"0", "3", "4"}, dropped: 2},
+		{policy: constant.DISPATCH_POLICY_COALESCE, expected: []string{"0", "1", "4"}, coalesced: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			d := NewEventDispatcher(1, 2, test.policy)
+			defer d.Close()
+			s := newBlockedSubscriber()
+			d.add(s, "public@@Test@@default", s.deliver)
+			d.dispatch(s, dispatchTask{oldService: dispatcherTestService(-1), service: dispatcherTestService(0)})
+			<-s.started
+			for version := 1; version < 5; version++ {
+				d.dispatch(s, dispatchTask{oldService: dispatcherTestService(version - 1), service: dispatcherTestService(version)})
+			}
+
+			metrics := d.Metrics()
+			assert.Equal(t, 1, metrics.Subscribers)
+			assert.Equal(t, 2, metrics.QueueDepth)
+			assert.Equal(t, 2, metrics.MaxQueueDepth)
+			assert.Equal(t, 2, metrics.QueueDepths["public@@Test@@default"])
+			assert.Equal(t, test.dropped, metrics.Dropped)
+			assert.Equal(t, test.coalesced, metrics.Coalesced)
+
+			close(s.release)
+			assert.True(t, d.waitIdle(time.Second))
+			assert.Equal(t, test.expected, s.checksums())
+			if test.policy == constant.DISPATCH_POLICY_COALESCE {
+				// 合并后的通知保留最早的 oldService，实例变化从合并前计算
+				assert.Equal(t, "1", s.received[2].oldService.Checksum)
+			}
+		})
+	}
+}
+
+// diffTestService 版本 version 的服务只有 10.0.0.<version> 一个实例，相邻版本之间新增一个、删除一个实例
+func diffTestService(version int) *model.Service {
+	return &model.Service{Name: "public@@Test", Clusters: "default", Checksum: strconv.Itoa(version),
+		Hosts: []model.Instance{{Ip: "10.0.0." + strconv.Itoa(version), Port: 80}}}
+}
+
+func TestEventDispatcher_OverflowCumulativeDiff(t *testing.T) {
+	for _, policy := range []string{constant.DISPATCH_POLICY_DROP_OLDEST, constant.DISPATCH_POLICY_DROP_NEWEST} {
+		t.Run(policy, func(t *testing.T) {
+			d := NewEventDispatcher(1, 2, policy)
+			defer d.Close()
+			s := newBlockedSubscriber()
+			d.add(s, "public@@Test@@default", s.deliver)
+			d.dispatch(s, dispatchTask{service: diffTestService(0)})
+			<-s.started
+			for version := 1; version < 8; version++ {
+				d.dispatch(s, dispatchTask{oldService: diffTestService(version - 1), service: diffTestService(version)})
+			}
+			close(s.release)
+			assert.True(t, d.waitIdle(time.Second))
+			// 队列有空位后的下一个通知同样从被丢弃的通知之前开始计算
+			d.dispatch(s, dispatchTask{oldService: diffTestService(7), service: diffTestService(8)})
+			assert.True(t, d.waitIdle(time.Second))
+			assert.Equal(t, uint64(5), d.Metrics().Dropped)
+
+			// 累计应用每次通知的 Added 和 Removed 后与最新的服务一致
+			hosts := map[string]bool{}
+			for _, task := range s.received {
+				event := newServiceEvent(task.oldService, task.service, nil)
+				for _, instance := range event.Removed {
+					assert.True(t, hosts[instance.Ip], instance.Ip)
+					delete(hosts, instance.Ip)
+				}
+				for _, instance := range event.Added {
+					assert.False(t, hosts[instance.Ip], instance.Ip)
+					hosts[instance.Ip] = true
+				}
+			}
+			assert.Equal(t, map[string]bool{"10.0.0.8": true}, hosts)
+		})
+	}
+}
+
+func TestEventDispatcher_DispatchAfterClose(t *testing.T) {
+	d := NewEventDispatcher(1, 0, "")
+	d.Close()
+	s := newBlockedSubscriber()
+	d.add(s, "public@@Test@@default", s.deliver)
+	d.dispatch(s, dispatchTask{service: dispatcherTestService(0)})
+	assert.True(t, d.waitIdle(10*time.Millisecond))
+	assert.Equal(t, 0, d.Metrics().Subscribers)
+}
+
+func TestEventDispatcher_Remove(t *testing.T) {
+	d := NewEventDispatcher(1, 0, "")
+	defer d.Close()
+	s := newBlockedSubscriber()
+	d.add(s, "public@@Test@@default", s.deliver)
+	d.dispatch(s, dispatchTask{service: dispatcherTestService(0)})
+	<-s.started
+	d.dispatch(s, dispatchTask{service: dispatcherTestService(1)})
+
+	d.remove(s)
+	// 移除后的通知被丢弃，不会重新创建队列
+	d.dispatch(s, dispatchTask{service: dispatcherTestService(2)})
+	close(s.release)
+	assert.True(t, d.waitIdle(time.Second))
+	assert.Equal(t, []string{"0"}, s.checksums())
+	assert.Equal(t, 0, d.Metrics().Subscribers)
+
+	// 重新添加时替换旧的队列，旧队列中尚未投递的通知被丢弃
+	other := newBlockedSubscriber()
+	d.add(s, "public@@Test@@default", other.deliver)
+	d.dispatch(s, dispatchTask{service: dispatcherTestService(3)})
+	<-other.started
+	d.dispatch(s, dispatchTask{service: dispatcherTestService(4)})
+	replaced := newBlockedSubscriber()
+	close(replaced.release)
+	d.add(s, "public@@Test@@default", replaced.deliver)
+	d.dispatch(s, dispatchTask{service: dispatcherTestService(5)})
+	close(other.release)
+	assert.True(t, d.waitIdle(time.Second))
+	assert.Equal(t, []string{"3"}, other.checksums())
+	assert.Equal(t, []string{"5"}, replaced.checksums())
+	assert.Equal(t, 1, d.Metrics().Subscribers)
+}
+
+func TestValidateOverflowPolicy(t *testing.T) {
+	assert.Nil(t, validateOverflowPolicy(""))
+	assert.Nil(t, validateOverflowPolicy(constant.DISPATCH_POLICY_DROP_OLDEST))
+	assert.NotNil(t, validateOverflowPolicy("block"))
+}
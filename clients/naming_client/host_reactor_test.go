@@ -1,9 +1,148 @@
 package naming_client
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/clients/cache"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/utils"
 )
 
 func TestHostReactor_GetServiceInfo(t *testing.T) {
 
 }
+
+func TestHostReactor_Close(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "naming")
+	defer os.RemoveAll(cacheDir)
+	hr := NewHostReactor(&NamingProxy{}, cacheDir, 1, true, NewSubscribeCallback(), false, 0)
+	service := model.Service{
+		Name:     "DEFAULT_GROUP@@DEMO",
+		Clusters: "a",
+		Hosts:    []model.Instance{{Ip: "10.0.0.10", Port: 80}},
+	}
+	hr.serviceInfoMap.Set(utils.GetServiceCacheKey(service.Name, service.Clusters), service)
+	time.Sleep(100 * time.Millisecond)
+
+	hr.Close()
+	hr.Close()
+
+	select {
+	case <-hr.done:
+	default:
+		t.Fatal("update loop should be stopped")
+	}
+	assert.True(t, hr.pushReceiver.isClosed())
+	services := cache.ReadServicesFromFile(cacheDir)
+	assert.Equal(t, 1, len(services))
+}
+
+func TestHostReactor_ProcessServiceJsonEvent(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "naming")
+	defer os.RemoveAll(cacheDir)
+	subCallback := NewSubscribeCallback()
+	hr := NewHostReactor(&NamingProxy{}, cacheDir, 1, true, subCallback, true, 0)
+	defer hr.Close()
+
+	var events []model.ServiceEvent
+	onEvent := func(event model.ServiceEvent) {
+		events = append(events, event)
+	}
+	subCallback.AddEventFuncs("DEFAULT_GROUP@@DEMO", "a", &onEvent)
+
+	hr.ProcessServiceJson(`{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,` +
+		`"hosts":[{"ip":"10.0.0.1","port":80,"weight":1},{"ip":"10.0.0.2","port":80,"weight":1}]}`)
+	hr.ProcessServiceJson(`{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,` +
+		`"hosts":[{"ip":"10.0.0.1","port":80,"weight":2},{"ip":"10.0.0.3","port":80,"weight":1}]}`)
+	// 实例没有变化时不通知
+	hr.ProcessServiceJson(`{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,` +
+		`"hosts":[{"ip":"10.0.0.1","port":80,"weight":2},{"ip":"10.0.0.3","port":80,"weight":1}]}`)
+
+	assert.True(t, subCallback.dispatcher.waitIdle(time.Second))
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, 2, len(events[0].Added))
+	assert.Equal(t, "10.0.0.3", events[1].Added[0].Ip)
+	assert.Equal(t, "10.0.0.2", events[1].Removed[0].Ip)
+	assert.Equal(t, "10.0.0.1", events[1].Modified[0].Ip)
+	assert.Equal(t, 2, len(events[1].Service.Hosts))
+}
+
+func TestHostReactor_ProcessServiceJsonEmpty(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "naming")
+	defer os.RemoveAll(cacheDir)
+	subCallback := NewSubscribeCallback()
+	hr := NewHostReactor(&NamingProxy{}, cacheDir, 1, true, subCallback, false, 0)
+	defer hr.Close()
+
+	var events []model.ServiceEvent
+	onEvent := func(event model.ServiceEvent) {
+		events = append(events, event)
+	}
+	subCallback.AddEventFuncs("DEFAULT_GROUP@@DEMO", "a", &onEvent)
+	// GetServiceInfo 首次查询前放入缓存的空服务不作为之前的状态
+	hr.serviceInfoMap.Set("DEFAULT_GROUP@@DEMO@@a", model.Service{Name: "DEFAULT_GROUP@@DEMO", Clusters: "a"})
+
+	hosts := `{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,"hosts":[{"ip":"10.0.0.1","port":80,"weight":1}]}`
+	empty := `{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,"hosts":[]}`
+	hr.ProcessServiceJson(hosts)
+	// 空列表不更新缓存，但仍然通知监听
+	hr.ProcessServiceJson(empty)
+	hr.ProcessServiceJson(empty)
+	cacheService, _ := hr.serviceInfoMap.Get("DEFAULT_GROUP@@DEMO@@a")
+	assert.Equal(t, 1, len(cacheService.(model.Service).Hosts))
+	// 恢复为与缓存相同的实例时同样通知
+	hr.ProcessServiceJson(hosts)
+
+	assert.True(t, subCallback.dispatcher.waitIdle(time.Second))
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, 1, len(events[0].Added))
+	assert.Equal(t, 0, len(events[0].Removed))
+	assert.Equal(t, 0, len(events[1].Service.Hosts))
+	assert.Equal(t, "10.0.0.1", events[1].Removed[0].Ip)
+	assert.Equal(t, "10.0.0.1", events[2].Added[0].Ip)
+}
+
+func TestHostReactor_EvictIdleServices(t *testing.T) {
+	cacheDir, _ := ioutil.TempDir("", "naming")
+	defer os.RemoveAll(cacheDir)
+	subCallback := NewSubscribeCallback()
+	hr := NewHostReactor(&NamingProxy{}, cacheDir, 1, true, subCallback, false, 100)
+	defer hr.Close()
+
+	now := uint64(utils.CurrentMillis())
+	for name, accessTime := range map[string]uint64{
+		"DEFAULT_GROUP@@IDLE":       now - 1000,
+		"DEFAULT_GROUP@@ACCESSED":   now,
+		"DEFAULT_GROUP@@SUBSCRIBED": now - 1000,
+	} {
+		key := utils.GetServiceCacheKey(name, "a")
+		hr.serviceInfoMap.Set(key, model.Service{Name: name, Clusters: "a", CacheMillis: 10000})
+		hr.accessTimeMap.Set(key, accessTime)
+	}
+	callback := func(services []model.SubscribeService, err error) {}
+	subCallback.AddCallbackFuncs("DEFAULT_GROUP@@SUBSCRIBED", "a", &callback)
+
+	assert.False(t, hr.isIdle(model.Service{Name: "DEFAULT_GROUP@@ACCESSED", Clusters: "a"}, now))
+	assert.False(t, hr.isIdle(model.Service{Name: "DEFAULT_GROUP@@SUBSCRIBED", Clusters: "a"}, now))
+	// 到期刷新时移除空闲的服务
+	_, err := hr.refreshService("DEFAULT_GROUP@@IDLE", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, hr.TrackedServiceCount())
+	assert.False(t, hr.serviceInfoMap.Has("DEFAULT_GROUP@@IDLE@@a"))
+	assert.True(t, hr.stoppedMap.Has("DEFAULT_GROUP@@IDLE@@a"))
+
+	// 被驱逐的服务不再处理推送
+	hr.ProcessServiceJson(`{"name":"DEFAULT_GROUP@@IDLE","clusters":"a","cacheMillis":10000,"hosts":[{"ip":"10.0.0.1","port":80}]}`)
+	assert.Equal(t, 2, hr.TrackedServiceCount())
+
+	// 取消订阅后同样会被驱逐
+	subCallback.RemoveCallbackFuncs("DEFAULT_GROUP@@SUBSCRIBED", "a", &callback)
+	_, err = hr.refreshService("DEFAULT_GROUP@@SUBSCRIBED", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, hr.TrackedServiceCount())
+	assert.True(t, hr.serviceInfoMap.Has("DEFAULT_GROUP@@ACCESSED@@a"))
+}
@@ -1,48 +1,74 @@
 package naming_client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"reflect"
+	"sync"
 	"time"
 
-	nsema "github.com/toolkits/concurrent/semaphore"
 	"github.com/uugtv/nacos-sdk-go/clients/cache"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
 )
 
 type HostReactor struct {
-	serviceInfoMap       cache.ConcurrentMap
-	cacheDir             string
-	updateThreadNum      int
-	serviceProxy         NamingProxy
-	pushReceiver         PushReceiver
-	subCallback          SubscribeCallback
-	updateTimeMap        cache.ConcurrentMap
+	serviceInfoMap  cache.ConcurrentMap
+	cacheDir        string
+	updateThreadNum int
+	serviceProxy    *NamingProxy
+	pushReceiver    *PushReceiver
+	subCallback     SubscribeCallback
+	scheduler       *updateScheduler
+	// 服务最近一次被读取的时间，没有订阅且超过 serviceIdleTimeMs 未被读取的服务会被移出缓存并停止刷新
+	accessTimeMap     cache.ConcurrentMap
+	serviceIdleTimeMs uint64
+	// 最近一次刷新失败的原因，刷新成功后移除
+	updateErrorMap cache.ConcurrentMap
+	// 最后一个订阅者取消后停止刷新的服务，不再处理这些服务的推送，直到再次获取
+	stoppedMap cache.ConcurrentMap
+	// 最近一次通知给监听的服务，计算实例变化时以它为准，不包含 GetServiceInfo 首次查询前放入缓存的空服务
+	notifiedMap          cache.ConcurrentMap
 	updateCacheWhenEmpty bool
+	done                 chan struct{}
+	closeOnce            *sync.Once
 }
 
-const Default_Update_Thread_Num = 20
+const (
+	Default_Update_Thread_Num    = 20
+	Default_Service_Idle_Time_Ms = 30 * 60 * 1000
+)
 
-func NewHostReactor(serviceProxy NamingProxy, cacheDir string, updateThreadNum int, notLoadCacheAtStart bool, subCallback SubscribeCallback, updateCacheWhenEmpty bool) HostReactor {
+func NewHostReactor(serviceProxy *NamingProxy, cacheDir string, updateThreadNum int, notLoadCacheAtStart bool, subCallback SubscribeCallback,
+	updateCacheWhenEmpty bool, serviceIdleTimeMs uint64) *HostReactor {
 	if updateThreadNum <= 0 {
 		updateThreadNum = Default_Update_Thread_Num
 	}
-	hr := HostReactor{
+	if serviceIdleTimeMs == 0 {
+		serviceIdleTimeMs = Default_Service_Idle_Time_Ms
+	}
+	hr := &HostReactor{
 		serviceProxy:         serviceProxy,
 		cacheDir:             cacheDir,
 		updateThreadNum:      updateThreadNum,
 		serviceInfoMap:       cache.NewConcurrentMap(),
 		subCallback:          subCallback,
-		updateTimeMap:        cache.NewConcurrentMap(),
+		accessTimeMap:        cache.NewConcurrentMap(),
+		serviceIdleTimeMs:    serviceIdleTimeMs,
+		updateErrorMap:       cache.NewConcurrentMap(),
+		stoppedMap:           cache.NewConcurrentMap(),
+		notifiedMap:          cache.NewConcurrentMap(),
 		updateCacheWhenEmpty: updateCacheWhenEmpty,
+		done:                 make(chan struct{}),
+		closeOnce:            &sync.Once{},
 	}
-	pr := NewPushRecevier(&hr)
-	hr.pushReceiver = *pr
+	hr.scheduler = newUpdateScheduler(updateThreadNum, realClock{}, hr.refreshService)
+	hr.pushReceiver = NewPushRecevier(hr)
 	if !notLoadCacheAtStart {
 		hr.loadCacheFromDisk()
 	}
-	go hr.asyncUpdateService()
 	return hr
 }
 
@@ -53,6 +79,8 @@ func (hr *HostReactor) loadCacheFromDisk() {
 	}
 	for k, v := range serviceMap {
 		hr.serviceInfoMap.Set(k, v)
+		hr.notifiedMap.Set(k, v)
+		hr.scheduler.schedule(v.Name, v.Clusters, cacheInterval(v))
 	}
 }
 
@@ -62,92 +90,175 @@ func (hr *HostReactor) ProcessServiceJson(result string) {
 		return
 	}
 	cacheKey := utils.GetServiceCacheKey(service.Name, service.Clusters)
+	if hr.stoppedMap.Has(cacheKey) {
+		logger.Debug("[HostReactor] service update is stopped, ignore it", logger.F("key", cacheKey))
+		return
+	}
+	hr.updateErrorMap.Remove(cacheKey)
+
+	// 先通知监听，实例列表为空时同样通知，即使下面不用它更新缓存
+	previous, notified := hr.notifiedMap.Get(cacheKey)
+	if !notified || !reflect.DeepEqual(service.Hosts, previous.(model.Service).Hosts) {
+		var oldService *model.Service
+		if notified {
+			old := previous.(model.Service)
+			oldService = &old
+		}
+		hr.notifiedMap.Set(cacheKey, *service)
+		hr.subCallback.serviceChanged(oldService, service)
+	}
 
-	oldDomain, ok := hr.serviceInfoMap.Get(cacheKey)
-	if ok && !hr.updateCacheWhenEmpty {
+	cacheService, ok := hr.serviceInfoMap.Get(cacheKey)
+	if ok && !hr.updateCacheWhenEmpty && len(cacheService.(model.Service).Hosts) > 0 {
 		//if instance list is empty,not to update cache
 		if service.Hosts == nil || len(service.Hosts) == 0 {
-			// log.Printf("[ERROR]:do not have useful host, ignore it, name:%s \n", service.Name)
+			logger.Error("[HostReactor] do not have useful host, ignore it", logger.F("name", service.Name))
+			hr.scheduler.schedule(service.Name, service.Clusters, cacheInterval(*service))
 			return
 		}
 	}
-	if !ok || ok && !reflect.DeepEqual(service.Hosts, oldDomain.(model.Service).Hosts) {
+	if !ok || !reflect.DeepEqual(service.Hosts, cacheService.(model.Service).Hosts) {
 		if !ok {
-			// log.Println("[INFO] service not found in cache " + cacheKey)
+			logger.Info("[HostReactor] service not found in cache", logger.F("key", cacheKey))
 		} else {
-			// log.Printf("[INFO] service key:%s was updated to:%s \n", cacheKey, utils.ToJsonString(service))
+			logger.Info("[HostReactor] service was updated", logger.F("key", cacheKey), logger.F("service", utils.ToJsonString(service)))
 		}
 		cache.WriteServicesToFile(*service, hr.cacheDir)
-		hr.subCallback.ServiceChanged(service)
 	}
-	hr.updateTimeMap.Set(cacheKey, uint64(utils.CurrentMillis()))
 	hr.serviceInfoMap.Set(cacheKey, *service)
+	hr.scheduler.schedule(service.Name, service.Clusters, cacheInterval(*service))
 }
 
-func (hr *HostReactor) GetServiceInfo(serviceName string, clusters string) model.Service {
+func cacheInterval(service model.Service) time.Duration {
+	return time.Duration(service.CacheMillis) * time.Millisecond
+}
+
+func (hr *HostReactor) GetServiceInfo(ctx context.Context, serviceName string, clusters string) model.Service {
 	key := utils.GetServiceCacheKey(serviceName, clusters)
+	hr.accessTimeMap.Set(key, uint64(utils.CurrentMillis()))
 	cacheService, ok := hr.serviceInfoMap.Get(key)
 	if !ok {
+		hr.stoppedMap.Remove(key)
 		cacheService = model.Service{Name: serviceName, Clusters: clusters}
 		hr.serviceInfoMap.Set(key, cacheService)
-		hr.updateServiceNow(serviceName, clusters)
+		if err := hr.updateServiceNow(ctx, serviceName, clusters); err != nil {
+			hr.scheduler.retry(serviceName, clusters, err)
+		}
 	}
 	newService, _ := hr.serviceInfoMap.Get(key)
 
 	return newService.(model.Service)
 }
 
-func (hr *HostReactor) GetAllServiceInfo(nameSpace string, groupName string, clusters string) []model.Service {
-	result, err := hr.serviceProxy.GetAllServiceInfoList(nameSpace, groupName, clusters)
+func (hr *HostReactor) GetAllServiceInfo(ctx context.Context, nameSpace string, groupName string, clusters string) []model.Service {
+	result, err := hr.serviceProxy.GetAllServiceInfoList(ctx, nameSpace, groupName, clusters)
 	if err != nil {
-		// log.Printf("[ERROR]:query all services info return error!nameSpace:%s cluster:%s groupName:%s  err:%s \n", nameSpace, clusters, groupName, err.Error())
+		logger.Error("[HostReactor] query all services info return error", logger.F("nameSpace", nameSpace), logger.F("clusters", clusters), logger.F("groupName", groupName), logger.Err(err))
 		return nil
 	}
 	if result == "" {
-		// log.Printf("[ERROR]:query all services info is empty!nameSpace:%s cluster:%s groupName:%s \n", nameSpace, clusters, groupName)
+		logger.Error("[HostReactor] query all services info is empty", logger.F("nameSpace", nameSpace), logger.F("clusters", clusters), logger.F("groupName", groupName))
 		return nil
 	}
 
 	var data []model.Service
 	err = json.Unmarshal([]byte(result), &data)
 	if err != nil {
-		// log.Printf("[ERROR]: the result of quering all services info json.Unmarshal error !nameSpace:%s cluster:%s groupName:%s \n", nameSpace, clusters, groupName)
+		logger.Error("[HostReactor] the result of quering all services info json.Unmarshal error", logger.F("nameSpace", nameSpace), logger.F("clusters", clusters), logger.F("groupName", groupName), logger.Err(err))
 		return nil
 	}
 	return data
 }
 
-func (hr *HostReactor) updateServiceNow(serviceName string, clusters string) {
-	result, err := hr.serviceProxy.QueryList(serviceName, clusters, hr.pushReceiver.port, false)
+func (hr *HostReactor) updateServiceNow(ctx context.Context, serviceName string, clusters string) error {
+	result, err := hr.serviceProxy.QueryList(ctx, serviceName, clusters, hr.pushReceiver.port, false)
 	if err != nil {
-		// log.Printf("[ERROR]:query list return error!servieName:%s cluster:%s  err:%s \n", serviceName, clusters, err.Error())
-		return
+		logger.Error("[HostReactor] query list return error", logger.F("serviceName", serviceName), logger.F("clusters", clusters), logger.Err(err))
+		hr.updateErrorMap.Set(utils.GetServiceCacheKey(serviceName, clusters), err)
+		return err
 	}
 	if result == "" {
-		// log.Printf("[ERROR]:query list is empty!servieName:%s cluster:%s \n", serviceName, clusters)
-		return
+		logger.Error("[HostReactor] query list is empty", logger.F("serviceName", serviceName), logger.F("clusters", clusters))
+		err = errors.New("query list is empty!")
+		hr.updateErrorMap.Set(utils.GetServiceCacheKey(serviceName, clusters), err)
+		return err
 	}
 	hr.ProcessServiceJson(result)
+	return nil
+}
+
+// refreshService 由调度器在服务到期时调用，空闲的服务被移除，否则重新查询服务，返回下次刷新的间隔
+func (hr *HostReactor) refreshService(serviceName string, clusters string) (time.Duration, error) {
+	cacheService, ok := hr.serviceInfoMap.Get(utils.GetServiceCacheKey(serviceName, clusters))
+	if !ok {
+		return 0, nil
+	}
+	if hr.isIdle(cacheService.(model.Service), uint64(utils.CurrentMillis())) {
+		logger.Info("[HostReactor] service is idle, evict it", logger.F("name", serviceName), logger.F("clusters", clusters))
+		hr.stopUpdateService(serviceName, clusters)
+		return 0, nil
+	}
+	if err := hr.updateServiceNow(context.Background(), serviceName, clusters); err != nil {
+		return 0, err
+	}
+	cacheService, ok = hr.serviceInfoMap.Get(utils.GetServiceCacheKey(serviceName, clusters))
+	if !ok {
+		return 0, nil
+	}
+	return cacheInterval(cacheService.(model.Service)), nil
+}
+
+func (hr *HostReactor) updateError(serviceName string, clusters string) error {
+	err, ok := hr.updateErrorMap.Get(utils.GetServiceCacheKey(serviceName, clusters))
+	if !ok {
+		return nil
+	}
+	return err.(error)
 }
 
-func (hr *HostReactor) asyncUpdateService() {
-	sema := nsema.NewSemaphore(hr.updateThreadNum)
-	for {
+// stopUpdateService 停止刷新服务并移出缓存，之后再获取该服务时会重新查询并恢复刷新
+func (hr *HostReactor) stopUpdateService(serviceName string, clusters string) {
+	key := utils.GetServiceCacheKey(serviceName, clusters)
+	logger.Info("[HostReactor] stop updating service", logger.F("key", key))
+	hr.stoppedMap.Set(key, true)
+	hr.serviceInfoMap.Remove(key)
+	hr.notifiedMap.Remove(key)
+	hr.scheduler.remove(serviceName, clusters)
+	hr.updateErrorMap.Remove(key)
+	hr.accessTimeMap.Remove(key)
+}
+
+// isIdle 服务没有订阅，并且超过 serviceIdleTimeMs 没有被读取
+func (hr *HostReactor) isIdle(service model.Service, now uint64) bool {
+	if hr.subCallback.hasListeners(service.Name, service.Clusters) {
+		return false
+	}
+	key := utils.GetServiceCacheKey(service.Name, service.Clusters)
+	accessTime, ok := hr.accessTimeMap.Get(key)
+	if !ok {
+		// 从磁盘缓存加载或只收到过推送的服务，从现在开始计算空闲时间
+		hr.accessTimeMap.SetIfAbsent(key, now)
+		return false
+	}
+	return now > accessTime.(uint64) && now-accessTime.(uint64) > hr.serviceIdleTimeMs
+}
+
+// TrackedServiceCount 返回缓存中正在后台刷新的服务数
+func (hr *HostReactor) TrackedServiceCount() int {
+	return hr.serviceInfoMap.Count()
+}
+
+// 停止更新服务、关闭 udp 监听，并把内存中的服务信息写入磁盘缓存
+func (hr *HostReactor) Close() {
+	hr.closeOnce.Do(func() {
+		close(hr.done)
+		hr.scheduler.stop()
+		hr.pushReceiver.Close()
 		for _, v := range hr.serviceInfoMap.Items() {
 			service := v.(model.Service)
-			lastRefTime, ok := hr.updateTimeMap.Get(utils.GetServiceCacheKey(service.Name, service.Clusters))
-			if !ok {
-				lastRefTime = uint64(0)
-			}
-			if uint64(utils.CurrentMillis())-lastRefTime.(uint64) > service.CacheMillis {
-				sema.Acquire()
-				go func() {
-					hr.updateServiceNow(service.Name, service.Clusters)
-					sema.Release()
-				}()
+			if service.Hosts != nil && len(service.Hosts) > 0 {
+				cache.WriteServicesToFile(service, hr.cacheDir)
 			}
 		}
-		time.Sleep(1 * time.Second)
-	}
-
+	})
 }
@@ -0,0 +1,73 @@
+package naming_client
+
+import (
+	"errors"
+
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+// instanceFilterOf 将 SelectInstancesParam 转换为过滤条件，未设置 Filter 时由 HealthyOnly 决定健康状态
+func instanceFilterOf(param vo.SelectInstancesParam) vo.InstanceFilter {
+	if param.Filter != nil {
+		return *param.Filter
+	}
+	if param.HealthyOnly {
+		return vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_HEALTHY}
+	}
+	return vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_ANY}
+}
+
+func validateInstanceFilter(filter vo.InstanceFilter) error {
+	switch filter.Health {
+	case "", constant.INSTANCE_HEALTH_ANY, constant.INSTANCE_HEALTH_HEALTHY, constant.INSTANCE_HEALTH_UNHEALTHY:
+	default:
+		return errors.New("[client.InstanceFilter] unsupported health:" + filter.Health)
+	}
+	switch filter.InstanceType {
+	case "", constant.INSTANCE_TYPE_ANY, constant.INSTANCE_TYPE_EPHEMERAL, constant.INSTANCE_TYPE_PERSISTENT:
+	default:
+		return errors.New("[client.InstanceFilter] unsupported instance type:" + filter.InstanceType)
+	}
+	if filter.ProtectThreshold < 0 || filter.ProtectThreshold > 1 {
+		return errors.New("[client.InstanceFilter] protect threshold must be between 0 and 1")
+	}
+	return nil
+}
+
+// filterInstances 按过滤条件筛选实例，先按启用状态、权重和实例类型筛选出候选实例，再按健康状态筛选
+// 只选择健康实例且健康实例在候选实例中的占比低于保护阈值时，返回全部候选实例
+func filterInstances(hosts []model.Instance, filter vo.InstanceFilter) ([]model.Instance, error) {
+	if err := validateInstanceFilter(filter); err != nil {
+		return nil, err
+	}
+	var candidates []model.Instance
+	for _, host := range hosts {
+		if !filter.IncludeDisabled && !host.Enable {
+			continue
+		}
+		if !filter.IncludeZeroWeight && host.Weight <= 0 {
+			continue
+		}
+		if filter.InstanceType == constant.INSTANCE_TYPE_EPHEMERAL && !host.Ephemeral ||
+			filter.InstanceType == constant.INSTANCE_TYPE_PERSISTENT && host.Ephemeral {
+			continue
+		}
+		candidates = append(candidates, host)
+	}
+	if filter.Health == "" || filter.Health == constant.INSTANCE_HEALTH_ANY {
+		return candidates, nil
+	}
+	healthy := filter.Health == constant.INSTANCE_HEALTH_HEALTHY
+	var result []model.Instance
+	for _, host := range candidates {
+		if host.Healthy == healthy {
+			result = append(result, host)
+		}
+	}
+	if healthy && len(candidates) > 0 && float64(len(result))/float64(len(candidates)) < filter.ProtectThreshold {
+		return candidates, nil
+	}
+	return result, nil
+}
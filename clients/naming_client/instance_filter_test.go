@@ -0,0 +1,92 @@
+package naming_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/loadbalancer"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+var filterInstancesTest = []model.Instance{
+	{Ip: "10.0.0.1", Weight: 1, Enable: true, Healthy: true, Ephemeral: true},
+	{Ip: "10.0.0.2", Weight: 1, Enable: true, Healthy: false, Ephemeral: true},
+	{Ip: "10.0.0.3", Weight: 1, Enable: false, Healthy: true, Ephemeral: true},
+	{Ip: "10.0.0.4", Weight: 0, Enable: true, Healthy: true, Ephemeral: true},
+	{Ip: "10.0.0.5", Weight: 1, Enable: true, Healthy: true, Ephemeral: false},
+}
+
+func filterIps(t *testing.T, hosts []model.Instance, filter vo.InstanceFilter) []string {
+	instances, err := filterInstances(hosts, filter)
+	assert.Nil(t, err)
+	ips := []string{}
+	for _, instance := range instances {
+		ips = append(ips, instance.Ip)
+	}
+	return ips
+}
+
+func TestFilterInstances(t *testing.T) {
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.5"}, filterIps(t, filterInstancesTest, vo.InstanceFilter{}))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.5"},
+		filterIps(t, filterInstancesTest, vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_HEALTHY}))
+	assert.Equal(t, []string{"10.0.0.2"},
+		filterIps(t, filterInstancesTest, vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_UNHEALTHY}))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.3", "10.0.0.5"},
+		filterIps(t, filterInstancesTest, vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_HEALTHY, IncludeDisabled: true}))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.4", "10.0.0.5"},
+		filterIps(t, filterInstancesTest, vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_HEALTHY, IncludeZeroWeight: true}))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"},
+		filterIps(t, filterInstancesTest, vo.InstanceFilter{InstanceType: constant.INSTANCE_TYPE_EPHEMERAL}))
+	assert.Equal(t, []string{"10.0.0.5"},
+		filterIps(t, filterInstancesTest, vo.InstanceFilter{InstanceType: constant.INSTANCE_TYPE_PERSISTENT}))
+
+	for _, filter := range []vo.InstanceFilter{{Health: "ok"}, {InstanceType: "temp"}, {ProtectThreshold: 2}} {
+		_, err := filterInstances(filterInstancesTest, filter)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestFilterInstances_ProtectThreshold(t *testing.T) {
+	// 候选实例 3 个，其中健康 2 个，占比约 0.67
+	filter := vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_HEALTHY, ProtectThreshold: 0.6}
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.5"}, filterIps(t, filterInstancesTest, filter))
+	filter.ProtectThreshold = 0.8
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.5"}, filterIps(t, filterInstancesTest, filter))
+
+	// 不选择健康实例时保护阈值不生效
+	filter.Health = constant.INSTANCE_HEALTH_UNHEALTHY
+	assert.Equal(t, []string{"10.0.0.2"}, filterIps(t, filterInstancesTest, filter))
+}
+
+func TestNamingClient_SelectInstances_NoMatch(t *testing.T) {
+	client := NamingClient{}
+	instances, err := client.selectInstances(model.Service{Hosts: filterInstancesTest},
+		vo.SelectInstancesParam{Filter: &vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_UNHEALTHY, InstanceType: constant.INSTANCE_TYPE_PERSISTENT}})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(instances))
+}
+
+func TestNamingClient_SelectOneHealthyInstance_ProtectThreshold(t *testing.T) {
+	client := NamingClient{loadBalancer: loadbalancer.NewRoundRobinBalancer()}
+	service := model.Service{Name: "DEFAULT_GROUP@@DEMO", Hosts: []model.Instance{
+		{Ip: "10.0.0.1", Weight: 1, Enable: true, Healthy: true},
+		{Ip: "10.0.0.2", Weight: 1, Enable: true, Healthy: false},
+		{Ip: "10.0.0.3", Weight: 1, Enable: true, Healthy: false},
+	}}
+	selected := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		instance, err := client.selectOneHealthyInstances(service, vo.SelectOneHealthInstanceParam{ProtectThreshold: 0.5})
+		assert.Nil(t, err)
+		selected[instance.Ip] = true
+	}
+	assert.Equal(t, 3, len(selected))
+
+	for i := 0; i < 3; i++ {
+		instance, err := client.selectOneHealthyInstances(service, vo.SelectOneHealthInstanceParam{})
+		assert.Nil(t, err)
+		assert.Equal(t, "10.0.0.1", instance.Ip)
+	}
+}
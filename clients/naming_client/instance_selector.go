@@ -0,0 +1,217 @@
+package naming_client
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+const (
+	selector_op_equals     = "="
+	selector_op_not_equals = "!="
+	selector_op_in         = "in"
+	selector_op_not_in     = "notin"
+	selector_op_exists     = "exists"
+	selector_op_not_exists = "!exists"
+
+	label_consumer_prefix = "CONSUMER.label."
+	label_provider_prefix = "PROVIDER.label."
+)
+
+// instanceSelector 在客户端按实例 Metadata 过滤实例，全部条件都满足时才匹配
+type instanceSelector struct {
+	requirements []selectorRequirement
+}
+
+type selectorRequirement struct {
+	key    string
+	op     string
+	values []string
+}
+
+// newInstanceSelector 解析选择器，selector 为空或类型为 none 时返回 nil，表示不过滤
+//
+// expression 类型（Type 为空时默认）支持以逗号或 & 分隔的多个条件：
+//   version=2、version==2、version!=2、zone in (a,b)、zone notin (a,b)、canary、!canary
+// label 类型兼容服务端的写法：CONSUMER.label.zone = PROVIDER.label.zone，
+// CONSUMER 的标签取自 labels（ClientConfig.Labels），PROVIDER 的标签为实例的 Metadata
+func newInstanceSelector(selector *model.ExpressionSelector, labels map[string]string) (*instanceSelector, error) {
+	if selector == nil {
+		return nil, nil
+	}
+	switch selector.Type {
+	case constant.SELECTOR_TYPE_NONE:
+		return nil, nil
+	case "", constant.SELECTOR_TYPE_EXPRESSION:
+		return parseExpressionSelector(selector.Expression)
+	case constant.SELECTOR_TYPE_LABEL:
+		return parseLabelSelector(selector.Expression, labels)
+	}
+	return nil, errors.New("[client.Selector] unsupported selector type:" + selector.Type)
+}
+
+func parseExpressionSelector(expression string) (*instanceSelector, error) {
+	s := &instanceSelector{}
+	for _, clause := range splitSelectorClauses(expression) {
+		requirement, err := parseRequirement(clause)
+		if err != nil {
+			return nil, err
+		}
+		s.requirements = append(s.requirements, requirement)
+	}
+	return s, nil
+}
+
+func parseLabelSelector(expression string, labels map[string]string) (*instanceSelector, error) {
+	s := &instanceSelector{}
+	for _, clause := range splitSelectorClauses(expression) {
+		parts := strings.SplitN(clause, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("[client.Selector] invalid label expression:" + clause)
+		}
+		left := strings.TrimSpace(strings.TrimSuffix(parts[0], "="))
+		right := strings.TrimSpace(strings.TrimPrefix(parts[1], "="))
+		if strings.HasPrefix(right, label_consumer_prefix) {
+			left, right = right, left
+		}
+		if !strings.HasPrefix(left, label_consumer_prefix) || !strings.HasPrefix(right, label_provider_prefix) {
+			return nil, errors.New("[client.Selector] invalid label expression:" + clause)
+		}
+		key := strings.TrimPrefix(right, label_provider_prefix)
+		value, ok := labels[strings.TrimPrefix(left, label_consumer_prefix)]
+		if ok {
+			s.requirements = append(s.requirements, selectorRequirement{key: key, op: selector_op_equals, values: []string{value}})
+		} else {
+			// 与服务端一致：消费者没有该标签时，只匹配同样没有该标签的实例
+			s.requirements = append(s.requirements, selectorRequirement{key: key, op: selector_op_not_exists})
+		}
+	}
+	return s, nil
+}
+
+// splitSelectorClauses 按逗号或 & 拆分条件，忽略括号内的逗号
+func splitSelectorClauses(expression string) []string {
+	var clauses []string
+	depth := 0
+	start := 0
+	appendClause := func(end int) {
+		clause := strings.TrimSpace(expression[start:end])
+		if len(clause) > 0 {
+			clauses = append(clauses, clause)
+		}
+	}
+	for i, c := range expression {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',', '&':
+			if depth == 0 {
+				appendClause(i)
+				start = i + 1
+			}
+		}
+	}
+	appendClause(len(expression))
+	return clauses
+}
+
+func parseRequirement(clause string) (selectorRequirement, error) {
+	if i := strings.Index(clause, "!="); i > 0 {
+		return newRequirement(clause[:i], selector_op_not_equals, clause[i+2:])
+	}
+	if i := strings.Index(clause, "=="); i > 0 {
+		return newRequirement(clause[:i], selector_op_equals, clause[i+2:])
+	}
+	if i := strings.Index(clause, "="); i > 0 {
+		return newRequirement(clause[:i], selector_op_equals, clause[i+1:])
+	}
+	if open := strings.Index(clause, "("); open > 0 {
+		if !strings.HasSuffix(clause, ")") {
+			return selectorRequirement{}, errors.New("[client.Selector] invalid expression:" + clause)
+		}
+		fields := strings.Fields(clause[:open])
+		if len(fields) != 2 || (fields[1] != selector_op_in && fields[1] != selector_op_not_in) {
+			return selectorRequirement{}, errors.New("[client.Selector] invalid expression:" + clause)
+		}
+		var values []string
+		for _, value := range strings.Split(clause[open+1:len(clause)-1], ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+		return selectorRequirement{key: fields[0], op: fields[1], values: values}, nil
+	}
+	key := strings.TrimSpace(clause)
+	op := selector_op_exists
+	if strings.HasPrefix(key, "!") {
+		key = strings.TrimSpace(key[1:])
+		op = selector_op_not_exists
+	}
+	if len(key) == 0 || strings.ContainsAny(key, " ()!=") {
+		return selectorRequirement{}, errors.New("[client.Selector] invalid expression:" + clause)
+	}
+	return selectorRequirement{key: key, op: op}, nil
+}
+
+func newRequirement(key string, op string, value string) (selectorRequirement, error) {
+	key = strings.TrimSpace(key)
+	if len(key) == 0 || strings.ContainsAny(key, " ()!=") {
+		return selectorRequirement{}, errors.New("[client.Selector] invalid expression:" + key + op + value)
+	}
+	return selectorRequirement{key: key, op: op, values: []string{strings.TrimSpace(value)}}, nil
+}
+
+func (r selectorRequirement) matches(metadata map[string]string) bool {
+	value, ok := metadata[r.key]
+	switch r.op {
+	case selector_op_equals:
+		return ok && value == r.values[0]
+	case selector_op_not_equals:
+		return !ok || value != r.values[0]
+	case selector_op_in:
+		return ok && containsString(r.values, value)
+	case selector_op_not_in:
+		return !ok || !containsString(r.values, value)
+	case selector_op_exists:
+		return ok
+	case selector_op_not_exists:
+		return !ok
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *instanceSelector) match(instance model.Instance) bool {
+	if s == nil {
+		return true
+	}
+	for _, requirement := range s.requirements {
+		if !requirement.matches(instance.Metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *instanceSelector) filter(instances []model.Instance) []model.Instance {
+	if s == nil {
+		return instances
+	}
+	result := make([]model.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if s.match(instance) {
+			result = append(result, instance)
+		}
+	}
+	return result
+}
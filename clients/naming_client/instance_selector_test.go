@@ -0,0 +1,67 @@
+package naming_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+var selectorInstancesTest = []model.Instance{
+	{Ip: "10.0.0.1", Metadata: map[string]string{"version": "1", "zone": "a"}},
+	{Ip: "10.0.0.2", Metadata: map[string]string{"version": "2", "zone": "a", "canary": "true"}},
+	{Ip: "10.0.0.3", Metadata: map[string]string{"version": "2", "zone": "b"}},
+	{Ip: "10.0.0.4", Metadata: map[string]string{"zone": "c"}},
+	{Ip: "10.0.0.5"},
+}
+
+func selectIps(t *testing.T, selector *model.ExpressionSelector, labels map[string]string) []string {
+	s, err := newInstanceSelector(selector, labels)
+	assert.Nil(t, err)
+	ips := []string{}
+	for _, instance := range s.filter(selectorInstancesTest) {
+		ips = append(ips, instance.Ip)
+	}
+	return ips
+}
+
+func TestInstanceSelector_Expression(t *testing.T) {
+	expression := func(e string) *model.ExpressionSelector {
+		return &model.ExpressionSelector{Expression: e}
+	}
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.3"}, selectIps(t, expression("version=2"), nil))
+	assert.Equal(t, []string{"10.0.0.2", "10.0.0.3"}, selectIps(t, expression("version == 2"), nil))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.4", "10.0.0.5"}, selectIps(t, expression("version!=2"), nil))
+	assert.Equal(t, []string{"10.0.0.2"}, selectIps(t, expression("version=2,zone=a"), nil))
+	assert.Equal(t, []string{"10.0.0.2"}, selectIps(t, expression("version=2 & zone=a"), nil))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, selectIps(t, expression("zone in (a, b)"), nil))
+	assert.Equal(t, []string{"10.0.0.4", "10.0.0.5"}, selectIps(t, expression("zone notin (a,b)"), nil))
+	assert.Equal(t, []string{"10.0.0.3"}, selectIps(t, expression("zone in (a,b),version=2,!canary"), nil))
+	assert.Equal(t, []string{"10.0.0.2"}, selectIps(t, expression("canary"), nil))
+	assert.Equal(t, 5, len(selectIps(t, expression(""), nil)))
+	assert.Equal(t, 5, len(selectIps(t, &model.ExpressionSelector{Type: constant.SELECTOR_TYPE_NONE, Expression: "version=2"}, nil)))
+	assert.Equal(t, 5, len(selectIps(t, nil, nil)))
+
+	for _, invalid := range []string{"=2", "zone in a,b", "zone between (a,b)", "zone in (a,b", "a b"} {
+		_, err := newInstanceSelector(expression(invalid), nil)
+		assert.NotNil(t, err, invalid)
+	}
+	_, err := newInstanceSelector(&model.ExpressionSelector{Type: "cmdb"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestInstanceSelector_Label(t *testing.T) {
+	label := func(e string) *model.ExpressionSelector {
+		return &model.ExpressionSelector{Type: constant.SELECTOR_TYPE_LABEL, Expression: e}
+	}
+	labels := map[string]string{"zone": "a", "version": "2"}
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, selectIps(t, label("CONSUMER.label.zone = PROVIDER.label.zone"), labels))
+	assert.Equal(t, []string{"10.0.0.2"},
+		selectIps(t, label("CONSUMER.label.zone = PROVIDER.label.zone & PROVIDER.label.version = CONSUMER.label.version"), labels))
+	// 消费者没有该标签时只匹配同样没有该标签的实例
+	assert.Equal(t, []string{"10.0.0.4", "10.0.0.5"}, selectIps(t, label("CONSUMER.label.version = PROVIDER.label.version"), nil))
+
+	_, err := newInstanceSelector(label("zone = a"), labels)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,80 @@
+package naming_client
+
+import (
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+// localityOf 合并单次调用的就近选择参数与客户端配置，未指定所在集群和可用区或关闭时返回 nil
+func localityOf(option *vo.LocalityOption, defaults vo.LocalityOption) *vo.LocalityOption {
+	locality := defaults
+	if option != nil {
+		if option.Disable {
+			return nil
+		}
+		if option.ClusterName != "" {
+			locality.ClusterName = option.ClusterName
+		}
+		if option.Zone != "" {
+			locality.Zone = option.Zone
+		}
+		if option.ZoneMetadataKey != "" {
+			locality.ZoneMetadataKey = option.ZoneMetadataKey
+		}
+		if option.Threshold > 0 {
+			locality.Threshold = option.Threshold
+		}
+	}
+	if locality.ClusterName == "" && locality.Zone == "" {
+		return nil
+	}
+	if locality.ZoneMetadataKey == "" {
+		locality.ZoneMetadataKey = constant.DEFAULT_ZONE_METADATA_KEY
+	}
+	return &locality
+}
+
+// selectLocalityTier 按同集群、同可用区、全部实例的顺序选择层级
+// 某一层没有可用实例，或者健康实例在该层可用实例中的占比低于阈值时，退到下一层
+func selectLocalityTier(hosts []model.Instance, locality *vo.LocalityOption) ([]model.Instance, string) {
+	if locality == nil {
+		return hosts, constant.LOCALITY_TIER_ALL
+	}
+	if locality.ClusterName != "" {
+		tier := hostsMatching(hosts, func(host model.Instance) bool {
+			return host.ClusterName == locality.ClusterName
+		})
+		if localityHealthy(tier, locality.Threshold) {
+			return tier, constant.LOCALITY_TIER_CLUSTER
+		}
+	}
+	if locality.Zone != "" {
+		tier := hostsMatching(hosts, func(host model.Instance) bool {
+			return host.Metadata[locality.ZoneMetadataKey] == locality.Zone
+		})
+		if localityHealthy(tier, locality.Threshold) {
+			return tier, constant.LOCALITY_TIER_ZONE
+		}
+	}
+	return hosts, constant.LOCALITY_TIER_ALL
+}
+
+func hostsMatching(hosts []model.Instance, match func(host model.Instance) bool) []model.Instance {
+	var result []model.Instance
+	for _, host := range hosts {
+		if match(host) {
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+func localityHealthy(hosts []model.Instance, threshold float64) bool {
+	available, _ := filterInstances(hosts, vo.InstanceFilter{})
+	healthy, _ := filterInstances(available, vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_HEALTHY})
+	if len(healthy) == 0 {
+		return false
+	}
+	return float64(len(healthy))/float64(len(available)) >= threshold
+}
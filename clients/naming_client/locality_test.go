@@ -0,0 +1,90 @@
+package naming_client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/loadbalancer"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+func localityHostsTest() []model.Instance {
+	return []model.Instance{
+		{Ip: "10.0.0.1", ClusterName: "a", Metadata: map[string]string{"zone": "z1"}, Weight: 1, Enable: true, Healthy: true},
+		{Ip: "10.0.0.2", ClusterName: "a", Metadata: map[string]string{"zone": "z1"}, Weight: 1, Enable: true, Healthy: false},
+		{Ip: "10.0.0.3", ClusterName: "b", Metadata: map[string]string{"zone": "z1"}, Weight: 1, Enable: true, Healthy: true},
+		{Ip: "10.0.0.4", ClusterName: "c", Metadata: map[string]string{"zone": "z2"}, Weight: 1, Enable: true, Healthy: true},
+	}
+}
+
+func TestLocalityOf(t *testing.T) {
+	assert.Nil(t, localityOf(nil, vo.LocalityOption{}))
+	assert.Nil(t, localityOf(&vo.LocalityOption{Zone: "z1", Disable: true}, vo.LocalityOption{Zone: "z1"}))
+
+	locality := localityOf(nil, vo.LocalityOption{Zone: "z1", Threshold: 0.5})
+	assert.Equal(t, &vo.LocalityOption{Zone: "z1", ZoneMetadataKey: constant.DEFAULT_ZONE_METADATA_KEY, Threshold: 0.5}, locality)
+
+	locality = localityOf(&vo.LocalityOption{ClusterName: "a", ZoneMetadataKey: "az"}, vo.LocalityOption{Zone: "z1", Threshold: 0.5})
+	assert.Equal(t, &vo.LocalityOption{ClusterName: "a", Zone: "z1", ZoneMetadataKey: "az", Threshold: 0.5}, locality)
+}
+
+func TestSelectLocalityTier(t *testing.T) {
+	hosts := localityHostsTest()
+
+	tierHosts, tier := selectLocalityTier(hosts, &vo.LocalityOption{ClusterName: "a", Zone: "z1", ZoneMetadataKey: "zone"})
+	assert.Equal(t, constant.LOCALITY_TIER_CLUSTER, tier)
+	assert.Equal(t, 2, len(tierHosts))
+
+	// 集群 a 健康占比 0.5，低于阈值时退到同可用区
+	tierHosts, tier = selectLocalityTier(hosts, &vo.LocalityOption{ClusterName: "a", Zone: "z1", ZoneMetadataKey: "zone", Threshold: 0.6})
+	assert.Equal(t, constant.LOCALITY_TIER_ZONE, tier)
+	assert.Equal(t, 3, len(tierHosts))
+
+	// 同可用区健康占比约 0.67，也低于阈值时使用全部实例
+	tierHosts, tier = selectLocalityTier(hosts, &vo.LocalityOption{ClusterName: "a", Zone: "z1", ZoneMetadataKey: "zone", Threshold: 0.7})
+	assert.Equal(t, constant.LOCALITY_TIER_ALL, tier)
+	assert.Equal(t, 4, len(tierHosts))
+
+	// 本集群没有实例
+	_, tier = selectLocalityTier(hosts, &vo.LocalityOption{ClusterName: "d", Zone: "z2", ZoneMetadataKey: "zone"})
+	assert.Equal(t, constant.LOCALITY_TIER_ZONE, tier)
+
+	_, tier = selectLocalityTier(hosts, nil)
+	assert.Equal(t, constant.LOCALITY_TIER_ALL, tier)
+}
+
+func TestNamingClient_SelectOneHealthyInstance_Locality(t *testing.T) {
+	client := NamingClient{
+		loadBalancer: loadbalancer.NewRoundRobinBalancer(),
+		locality:     vo.LocalityOption{Zone: "z2"},
+	}
+	service := model.Service{Name: "DEFAULT_GROUP@@DEMO", Hosts: localityHostsTest()}
+	for i := 0; i < 3; i++ {
+		instance, tier, err := client.selectOneHealthyInstanceWithTier(service, vo.SelectOneHealthInstanceParam{})
+		assert.Nil(t, err)
+		assert.Equal(t, constant.LOCALITY_TIER_ZONE, tier)
+		assert.Equal(t, "10.0.0.4", instance.Ip)
+	}
+
+	// 单次调用指定集群
+	instance, tier, err := client.selectOneHealthyInstanceWithTier(service, vo.SelectOneHealthInstanceParam{
+		Locality: &vo.LocalityOption{ClusterName: "b"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, constant.LOCALITY_TIER_CLUSTER, tier)
+	assert.Equal(t, "10.0.0.3", instance.Ip)
+
+	// 关闭就近选择
+	selected := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		instance, tier, err := client.selectOneHealthyInstanceWithTier(service, vo.SelectOneHealthInstanceParam{
+			Locality: &vo.LocalityOption{Disable: true},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, constant.LOCALITY_TIER_ALL, tier)
+		selected[instance.Ip] = true
+	}
+	assert.Equal(t, 3, len(selected))
+}
@@ -1,16 +1,16 @@
 package naming_client
 
 import (
-	"math"
-	"math/rand"
+	"context"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/uugtv/nacos-sdk-go/clients/cache"
 	"github.com/uugtv/nacos-sdk-go/clients/nacos_client"
 	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/loadbalancer"
 	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
@@ -19,15 +19,17 @@ import (
 
 type NamingClient struct {
 	nacos_client.INacosClient
-	hostReactor  HostReactor
-	serviceProxy NamingProxy
+	hostReactor  *HostReactor
+	serviceProxy *NamingProxy
 	subCallback  SubscribeCallback
 	beatReactor  BeatReactor
-	indexMap     cache.ConcurrentMap
+	loadBalancer loadbalancer.LoadBalancer
+	locality     vo.LocalityOption
 }
 
 func NewNamingClient(nc nacos_client.INacosClient) (NamingClient, error) {
 	naming := NamingClient{}
+	naming.INacosClient = nc
 	clientConfig, err :=
 		nc.GetClientConfig()
 	if err != nil {
@@ -41,29 +43,74 @@ func NewNamingClient(nc nacos_client.INacosClient) (NamingClient, error) {
 	if err != nil {
 		return naming, err
 	}
-	err = logger.InitLog(clientConfig.LogDir)
+	err = logger.InitLogWithLevel(clientConfig.LogDir, clientConfig.LogLevel)
 	if err != nil {
 		return naming, err
 	}
-	naming.subCallback = NewSubscribeCallback()
+	if err = validateOverflowPolicy(clientConfig.CallbackOverflowPolicy); err != nil {
+		return naming, err
+	}
+	naming.subCallback = NewSubscribeCallbackWithDispatcher(NewEventDispatcher(clientConfig.CallbackThreadNum,
+		clientConfig.CallbackQueueSize, clientConfig.CallbackOverflowPolicy))
 	naming.serviceProxy, err = NewNamingProxy(clientConfig, serverConfig, httpAgent)
 	if err != nil {
 		return naming, err
 	}
 	naming.hostReactor = NewHostReactor(naming.serviceProxy, clientConfig.CacheDir+string(os.PathSeparator)+"naming",
-		clientConfig.UpdateThreadNum, clientConfig.NotLoadCacheAtStart, naming.subCallback, clientConfig.UpdateCacheWhenEmpty)
-	naming.beatReactor = NewBeatReactor(naming.serviceProxy, clientConfig.BeatInterval)
-	naming.indexMap = cache.NewConcurrentMap()
+		clientConfig.UpdateThreadNum, clientConfig.NotLoadCacheAtStart, naming.subCallback, clientConfig.UpdateCacheWhenEmpty,
+		clientConfig.ServiceIdleTimeMs)
+	naming.beatReactor = NewBeatReactor(naming.serviceProxy, clientConfig.BeatInterval, clientConfig.BeatThreadNum,
+		clientConfig.BeatFailureThreshold)
+	naming.loadBalancer, err = loadbalancer.New(clientConfig.LoadBalancer)
+	if err != nil {
+		return naming, err
+	}
+	if _, ok := naming.loadBalancer.(loadbalancer.ActiveTracker); ok {
+		logger.Warn("[NamingClient] load balancer selects by active requests, only SelectOneHealthyInstanceWithRelease tracks them",
+			logger.F("loadBalancer", clientConfig.LoadBalancer))
+	}
+	naming.locality = vo.LocalityOption{
+		ClusterName:     clientConfig.ClusterName,
+		Zone:            clientConfig.Zone,
+		ZoneMetadataKey: clientConfig.ZoneMetadataKey,
+		Threshold:       clientConfig.LocalityThreshold,
+	}
 
 	return naming, nil
 }
 
 // 注册服务实例
 func (sc *NamingClient) RegisterInstance(param vo.RegisterInstanceParam) (bool, error) {
+	return sc.RegisterInstanceWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) RegisterInstanceWithContext(ctx context.Context, param vo.RegisterInstanceParam) (bool, error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
-	instance := model.Instance{
+	instance := buildInstance(param)
+	beatInfo := model.BeatInfo{
+		Ip:          param.Ip,
+		Port:        param.Port,
+		Metadata:    param.Metadata,
+		ServiceName: utils.GetGroupName(param.ServiceName, param.GroupName),
+		Cluster:     param.ClusterName,
+		Weight:      param.Weight,
+		Period:      utils.GetDurationWithDefault(param.Metadata, constant.HEART_BEAT_INTERVAL, time.Second*5),
+	}
+	_, err := sc.serviceProxy.RegisterInstance(ctx, utils.GetGroupName(param.ServiceName, param.GroupName), param.GroupName, instance)
+	if err != nil {
+		return false, err
+	}
+	if instance.Ephemeral {
+		sc.beatReactor.AddRegisteredInstance(param, beatInfo)
+	}
+	return true, nil
+
+}
+
+func buildInstance(param vo.RegisterInstanceParam) model.Instance {
+	return model.Instance{
 		Ip:          param.Ip,
 		Port:        param.Port,
 		Metadata:    param.Metadata,
@@ -73,34 +120,48 @@ func (sc *NamingClient) RegisterInstance(param vo.RegisterInstanceParam) (bool,
 		Weight:      param.Weight,
 		Ephemeral:   param.Ephemeral,
 	}
-	beatInfo := model.BeatInfo{
+}
+
+// 更新服务实例
+func (sc *NamingClient) UpdateInstance(param vo.UpdateInstanceParam) (bool, error) {
+	return sc.UpdateInstanceWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) UpdateInstanceWithContext(ctx context.Context, param vo.UpdateInstanceParam) (bool, error) {
+	if param.GroupName == "" {
+		param.GroupName = constant.DEFAULT_GROUP
+	}
+	serviceName := utils.GetGroupName(param.ServiceName, param.GroupName)
+	instance := model.Instance{
 		Ip:          param.Ip,
 		Port:        param.Port,
 		Metadata:    param.Metadata,
-		ServiceName: utils.GetGroupName(param.ServiceName, param.GroupName),
-		Cluster:     param.ClusterName,
+		ClusterName: param.ClusterName,
+		Enable:      sc.beatReactor.enableOf(serviceName, param),
 		Weight:      param.Weight,
-		Period:      utils.GetDurationWithDefault(param.Metadata, constant.HEART_BEAT_INTERVAL, time.Second*5),
+		Ephemeral:   param.Ephemeral,
 	}
-	_, err := sc.serviceProxy.RegisterInstance(utils.GetGroupName(param.ServiceName, param.GroupName), param.GroupName, instance)
+	_, err := sc.serviceProxy.UpdateInstance(ctx, serviceName, param.GroupName, instance)
 	if err != nil {
 		return false, err
 	}
-	if instance.Ephemeral {
-		sc.beatReactor.AddBeatInfo(utils.GetGroupName(param.ServiceName, param.GroupName), beatInfo)
-	}
+	// 实例没有在发送心跳时不做处理
+	sc.beatReactor.UpdateBeatInfo(param)
 	return true, nil
-
 }
 
 // 注销服务实例
 func (sc *NamingClient) DeregisterInstance(param vo.DeregisterInstanceParam) (bool, error) {
+	return sc.DeregisterInstanceWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) DeregisterInstanceWithContext(ctx context.Context, param vo.DeregisterInstanceParam) (bool, error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
 	sc.beatReactor.RemoveBeatInfo(utils.GetGroupName(param.ServiceName, param.GroupName), param.Ip, param.Port)
 
-	_, err := sc.serviceProxy.DeregisterInstance(utils.GetGroupName(param.ServiceName, param.GroupName), param.Ip, param.Port, param.Cluster, param.Ephemeral)
+	_, err := sc.serviceProxy.DeregisterInstance(ctx, utils.GetGroupName(param.ServiceName, param.GroupName), param.Ip, param.Port, param.Cluster, param.Ephemeral)
 	if err != nil {
 		return false, err
 	}
@@ -109,29 +170,50 @@ func (sc *NamingClient) DeregisterInstance(param vo.DeregisterInstanceParam) (bo
 
 // 获取服务列表
 func (sc *NamingClient) GetService(param vo.GetServiceParam) (model.Service, error) {
+	return sc.GetServiceWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) GetServiceWithContext(ctx context.Context, param vo.GetServiceParam) (model.Service, error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
-	service := sc.hostReactor.GetServiceInfo(utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
+	service := sc.hostReactor.GetServiceInfo(ctx, utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
+	if ctx.Err() != nil {
+		return model.Service{}, ctx.Err()
+	}
 	return service, nil
 }
 
 func (sc *NamingClient) GetAllServicesInfo(param vo.GetAllServiceInfoParam) ([]model.Service, error) {
+	return sc.GetAllServicesInfoWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) GetAllServicesInfoWithContext(ctx context.Context, param vo.GetAllServiceInfoParam) ([]model.Service, error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
 	if param.NameSpace == "" {
 		param.NameSpace = constant.DEFAULT_NAMESPACE_ID
 	}
-	service := sc.hostReactor.GetAllServiceInfo(param.NameSpace, param.GroupName, strings.Join(param.Clusters, ","))
+	service := sc.hostReactor.GetAllServiceInfo(ctx, param.NameSpace, param.GroupName, strings.Join(param.Clusters, ","))
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	return service, nil
 }
 
 func (sc *NamingClient) SelectAllInstances(param vo.SelectAllInstancesParam) ([]model.Instance, error) {
+	return sc.SelectAllInstancesWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) SelectAllInstancesWithContext(ctx context.Context, param vo.SelectAllInstancesParam) ([]model.Instance, error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
-	service := sc.hostReactor.GetServiceInfo(utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
+	service := sc.hostReactor.GetServiceInfo(ctx, utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
+	if ctx.Err() != nil {
+		return []model.Instance{}, ctx.Err()
+	}
 	if service.Hosts == nil || len(service.Hosts) == 0 {
 		return []model.Instance{}, errors.New("instance list is empty!")
 	}
@@ -139,111 +221,242 @@ func (sc *NamingClient) SelectAllInstances(param vo.SelectAllInstancesParam) ([]
 }
 
 func (sc *NamingClient) SelectInstances(param vo.SelectInstancesParam) ([]model.Instance, error) {
+	return sc.SelectInstancesWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) SelectInstancesWithContext(ctx context.Context, param vo.SelectInstancesParam) ([]model.Instance, error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
-	service := sc.hostReactor.GetServiceInfo(utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
-	return sc.selectInstances(service, param.HealthyOnly)
+	selector, err := sc.newInstanceSelector(param.Selector)
+	if err != nil {
+		return []model.Instance{}, err
+	}
+	service := sc.hostReactor.GetServiceInfo(ctx, utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
+	if ctx.Err() != nil {
+		return []model.Instance{}, ctx.Err()
+	}
+	service.Hosts = selector.filter(service.Hosts)
+	return sc.selectInstances(service, param)
+}
+
+func (sc *NamingClient) newInstanceSelector(selector *model.ExpressionSelector) (*instanceSelector, error) {
+	clientConfig, _ := sc.GetClientConfig()
+	return newInstanceSelector(selector, clientConfig.Labels)
 }
 
-func (sc *NamingClient) selectInstances(service model.Service, healthy bool) ([]model.Instance, error) {
+func (sc *NamingClient) selectInstances(service model.Service, param vo.SelectInstancesParam) ([]model.Instance, error) {
 	if service.Hosts == nil || len(service.Hosts) == 0 {
 		return []model.Instance{}, errors.New("instance list is empty!")
 	}
-	hosts := service.Hosts
-	var result []model.Instance
-	for _, host := range hosts {
-		if host.Healthy == healthy && host.Enable && host.Weight > 0 {
-			result = append(result, host)
-		}
+	result, err := filterInstances(service.Hosts, instanceFilterOf(param))
+	if err != nil {
+		return []model.Instance{}, err
+	}
+	if len(result) == 0 {
+		return []model.Instance{}, errors.New("no instance matches the filter!")
 	}
 	return result, nil
 }
 
 func (sc *NamingClient) SelectOneHealthyInstance(param vo.SelectOneHealthInstanceParam) (*model.Instance, error) {
+	return sc.SelectOneHealthyInstanceWithContext(context.Background(), param)
+}
+
+func (sc *NamingClient) SelectOneHealthyInstanceWithContext(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, error) {
+	instance, _, err := sc.SelectOneHealthyInstanceWithLocality(ctx, param)
+	return instance, err
+}
+
+// SelectOneHealthyInstanceWithLocality 获取一个健康的实例，并返回所用的就近选择层级
+// 不跟踪请求，least_active 和 p2c 看到的活跃请求数始终为零，使用它们时应调用 SelectOneHealthyInstanceWithRelease
+func (sc *NamingClient) SelectOneHealthyInstanceWithLocality(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, error) {
+	instance, tier, release, err := sc.selectOneHealthyInstance(ctx, param)
+	if err != nil {
+		return nil, "", err
+	}
+	// 调用方不会通知请求结束，立即释放，避免 least_active、p2c 的活跃请求数只增不减
+	release()
+	return instance, tier, nil
+}
+
+// SelectOneHealthyInstanceWithRelease 获取一个健康的实例，并返回所用的就近选择层级，调用方在请求结束后调用 release，
+// least_active 和 p2c 据此减少实例的活跃请求数，是使用这两种负载均衡时唯一能正确计数的方式；release 可以重复调用，只生效一次
+func (sc *NamingClient) SelectOneHealthyInstanceWithRelease(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, func(), error) {
+	instance, tier, release, err := sc.selectOneHealthyInstance(ctx, param)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return instance, tier, release, nil
+}
+
+func (sc *NamingClient) selectOneHealthyInstance(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, func(), error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
-	service := sc.hostReactor.GetServiceInfo(utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
-	return sc.selectOneHealthyInstances(service)
+	selector, err := sc.newInstanceSelector(param.Selector)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	service := sc.hostReactor.GetServiceInfo(ctx, utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","))
+	if ctx.Err() != nil {
+		return nil, "", nil, ctx.Err()
+	}
+	service.Hosts = selector.filter(service.Hosts)
+	return sc.acquireOneHealthyInstance(service, param)
+}
+
+func (sc *NamingClient) selectOneHealthyInstances(service model.Service, param vo.SelectOneHealthInstanceParam) (*model.Instance, error) {
+	instance, _, err := sc.selectOneHealthyInstanceWithTier(service, param)
+	return instance, err
 }
 
-func (sc *NamingClient) selectOneHealthyInstances(service model.Service) (*model.Instance, error) {
+func (sc *NamingClient) selectOneHealthyInstanceWithTier(service model.Service, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, error) {
+	instance, tier, release, err := sc.acquireOneHealthyInstance(service, param)
+	if err != nil {
+		return nil, "", err
+	}
+	release()
+	return instance, tier, nil
+}
+
+// acquireOneHealthyInstance 选出一个健康的实例，返回的 release 以选择时的 serviceKey 通知负载均衡请求结束
+func (sc *NamingClient) acquireOneHealthyInstance(service model.Service, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, func(), error) {
 	if service.Hosts == nil || len(service.Hosts) == 0 {
-		return nil, errors.New("instance list is empty!")
-	}
-	hosts := service.Hosts
-	var result []model.Instance
-	mw := 0
-	for _, host := range hosts {
-		if host.Healthy && host.Enable && host.Weight > 0 {
-			cw := int(math.Ceil(host.Weight))
-			if cw > mw {
-				mw = cw
-			}
-			result = append(result, host)
-		}
+		return nil, "", nil, errors.New("instance list is empty!")
+	}
+	hosts, tier := selectLocalityTier(service.Hosts, localityOf(param.Locality, sc.locality))
+	result, err := filterInstances(hosts, vo.InstanceFilter{
+		Health:           constant.INSTANCE_HEALTH_HEALTHY,
+		ProtectThreshold: param.ProtectThreshold,
+	})
+	if err != nil {
+		return nil, "", nil, err
 	}
 	if len(result) == 0 {
-		return nil, errors.New("healthy instance list is empty!")
+		return nil, "", nil, errors.New("healthy instance list is empty!")
 	}
-
-	randomInstances := random(result, mw)
-	key := utils.GetServiceCacheKey(service.Name, service.Clusters)
-	i, indexOk := sc.indexMap.Get(key)
-	var index int
-
-	if !indexOk {
-		index = rand.Intn(len(randomInstances))
-	} else {
-		index = i.(int)
-		index += 1
-		if index >= len(randomInstances) {
-			index = index % len(randomInstances)
-		}
+	balancer := param.LoadBalancer
+	if balancer == nil {
+		balancer = sc.loadBalancer
 	}
-
-	sc.indexMap.Set(key, index)
-	return &randomInstances[index], nil
+	// 不同层级的实例集合不同，各自维护负载均衡状态
+	serviceKey := utils.GetServiceCacheKey(service.Name, service.Clusters)
+	if tier != constant.LOCALITY_TIER_ALL {
+		serviceKey += constant.SERVICE_INFO_SPLITER + tier
+	}
+	instance, err := balancer.Select(serviceKey, result, param.HashKey)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	logger.Debug("[NamingClient] select one healthy instance", logger.F("service", service.Name),
+		logger.F("tier", tier), logger.F("instance", instance.Ip))
+	return instance, tier, releaseOf(balancer, serviceKey, *instance), nil
 }
 
-func random(instances []model.Instance, mw int) []model.Instance {
-	if len(instances) <= 1 || mw <= 1 {
-		return instances
+func releaseOf(balancer loadbalancer.LoadBalancer, serviceKey string, instance model.Instance) func() {
+	tracker, ok := balancer.(loadbalancer.ActiveTracker)
+	if !ok {
+		return func() {}
 	}
-	//实例交叉插入列表，避免列表中是连续的实例
-	var result = make([]model.Instance, 0)
-	for i := 1; i <= mw; i++ {
-		for _, host := range instances {
-			if int(math.Ceil(host.Weight)) >= i {
-				result = append(result, host)
-			}
-		}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			tracker.Done(serviceKey, instance)
+		})
 	}
-	return result
 }
 
 // 服务监听
-func (sc *NamingClient) Subscribe(param *vo.SubscribeParam) error {
+func (sc *NamingClient) Subscribe(param *vo.SubscribeParam) (model.Subscription, error) {
 	if param.GroupName == "" {
 		param.GroupName = constant.DEFAULT_GROUP
 	}
+	if param.SubscribeCallback == nil && param.OnEvent == nil {
+		return nil, errors.New("[client.Subscribe] param.SubscribeCallback and param.OnEvent can not both be empty")
+	}
 	serviceParam := vo.GetServiceParam{
 		ServiceName: param.ServiceName,
 		GroupName:   param.GroupName,
 		Clusters:    param.Clusters,
 	}
 
-	sc.subCallback.AddCallbackFuncs(utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","), &param.SubscribeCallback)
-	_, err := sc.GetService(serviceParam)
+	selector, err := sc.newInstanceSelector(param.Selector)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	serviceName := utils.GetGroupName(param.ServiceName, param.GroupName)
+	clusters := strings.Join(param.Clusters, ",")
+	if param.SubscribeCallback != nil {
+		sc.subCallback.addCallbackFuncsWithSelector(serviceName, clusters, &param.SubscribeCallback, selector)
+	}
+	_, cached := sc.hostReactor.serviceInfoMap.Get(utils.GetServiceCacheKey(serviceName, clusters))
+	if param.OnEvent != nil {
+		sc.subCallback.addEventFuncsWithSelector(serviceName, clusters, &param.OnEvent, selector)
+	}
+	service, err := sc.GetService(serviceParam)
+	if err != nil {
+		sc.Unsubscribe(param)
+		return nil, err
+	}
+	// 服务已经在缓存中时不会再触发变更通知，直接推送一次当前快照
+	if param.OnEvent != nil && cached {
+		sc.subCallback.notifyEvent(&param.OnEvent, nil, &service)
+	}
+	return newSubscription(sc, param, selector), nil
 }
 
-//取消服务监听
+//取消服务监听，param 需要是订阅时传入的同一个参数；服务的最后一个监听取消后停止后台刷新该服务
 func (sc *NamingClient) Unsubscribe(param *vo.SubscribeParam) error {
-	sc.subCallback.RemoveCallbackFuncs(utils.GetGroupName(param.ServiceName, param.GroupName), strings.Join(param.Clusters, ","), &param.SubscribeCallback)
+	if param.GroupName == "" {
+		param.GroupName = constant.DEFAULT_GROUP
+	}
+	serviceName := utils.GetGroupName(param.ServiceName, param.GroupName)
+	clusters := strings.Join(param.Clusters, ",")
+	sc.subCallback.RemoveCallbackFuncs(serviceName, clusters, &param.SubscribeCallback)
+	sc.subCallback.RemoveEventFuncs(serviceName, clusters, &param.OnEvent)
+	if !sc.subCallback.hasListeners(serviceName, clusters) {
+		sc.hostReactor.stopUpdateService(serviceName, clusters)
+		// 停止期间有新的订阅时重新获取服务，恢复刷新
+		if sc.subCallback.hasListeners(serviceName, clusters) {
+			sc.hostReactor.GetServiceInfo(context.Background(), serviceName, clusters)
+		}
+	}
 	return nil
 }
+
+// 关闭客户端：停止心跳并注销临时实例，停止服务更新和 udp 监听
+func (sc *NamingClient) Close() error {
+	var err error
+	for _, beatInfo := range sc.beatReactor.Close() {
+		_, deregisterErr := sc.serviceProxy.DeregisterInstance(context.Background(), beatInfo.ServiceName, beatInfo.Ip, beatInfo.Port, beatInfo.Cluster, true)
+		if deregisterErr != nil && err == nil {
+			err = errors.Wrapf(deregisterErr, "deregister instance %s:%d of %s failed", beatInfo.Ip, beatInfo.Port, beatInfo.ServiceName)
+		}
+	}
+	sc.hostReactor.Close()
+	sc.subCallback.dispatcher.Close()
+	return err
+}
+
+// 获取本客户端注册的临时实例的心跳状态
+func (sc *NamingClient) GetBeatStatus(param vo.GetBeatStatusParam) (model.BeatStatus, error) {
+	if param.GroupName == "" {
+		param.GroupName = constant.DEFAULT_GROUP
+	}
+	status, ok := sc.beatReactor.GetBeatStatus(utils.GetGroupName(param.ServiceName, param.GroupName), param.Ip, param.Port)
+	if !ok {
+		return status, errors.New("instance is not registered!")
+	}
+	return status, nil
+}
+
+// 获取客户端正在缓存并后台刷新的服务数
+func (sc *NamingClient) GetTrackedServiceCount() int {
+	return sc.hostReactor.TrackedServiceCount()
+}
+
+// 获取订阅回调的队列深度、丢弃和合并的通知数
+func (sc *NamingClient) GetDispatcherMetrics() model.DispatcherMetrics {
+	return sc.subCallback.dispatcher.Metrics()
+}
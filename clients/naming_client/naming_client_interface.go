@@ -1,6 +1,8 @@
 package naming_client
 
 import (
+	"context"
+
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/vo"
 )
@@ -21,6 +23,8 @@ type INamingClient interface {
 	RegisterInstance(param vo.RegisterInstanceParam) (bool, error)
 	// 注销服务实例
 	DeregisterInstance(param vo.DeregisterInstanceParam) (bool, error)
+	// 更新服务实例的权重、元数据等信息，不需要重新注册，之后的心跳携带新的信息
+	UpdateInstance(param vo.UpdateInstanceParam) (bool, error)
 	// 获取服务信息
 	GetService(param vo.GetServiceParam) (model.Service, error)
 	//获取所有的实例列表
@@ -29,11 +33,40 @@ type INamingClient interface {
 	SelectInstances(param vo.SelectInstancesParam) ([]model.Instance, error)
 	//获取一个健康的实例
 	SelectOneHealthyInstance(param vo.SelectOneHealthInstanceParam) (*model.Instance, error)
-	// 服务监听
-	Subscribe(param *vo.SubscribeParam) error
-	//取消监听
+	// 服务监听，返回的订阅句柄可以取消订阅、获取当前的服务快照和最近一次刷新失败的原因
+	Subscribe(param *vo.SubscribeParam) (model.Subscription, error)
+	//取消监听，param 需要是订阅时传入的同一个参数
 	Unsubscribe(param *vo.SubscribeParam) error
+	// 以 channel 的形式监听服务，ctx 取消或客户端关闭后取消监听并关闭 channel
+	Watch(ctx context.Context, param vo.SubscribeParam) (<-chan model.ServiceEvent, error)
 
 	//获取全部服务信息
 	GetAllServicesInfo(param vo.GetAllServiceInfoParam) ([]model.Service, error)
+
+	// 以下为支持 context 的版本，ctx 取消或超时后立即返回
+	RegisterInstanceWithContext(ctx context.Context, param vo.RegisterInstanceParam) (bool, error)
+	DeregisterInstanceWithContext(ctx context.Context, param vo.DeregisterInstanceParam) (bool, error)
+	UpdateInstanceWithContext(ctx context.Context, param vo.UpdateInstanceParam) (bool, error)
+	GetServiceWithContext(ctx context.Context, param vo.GetServiceParam) (model.Service, error)
+	SelectAllInstancesWithContext(ctx context.Context, param vo.SelectAllInstancesParam) ([]model.Instance, error)
+	SelectInstancesWithContext(ctx context.Context, param vo.SelectInstancesParam) ([]model.Instance, error)
+	SelectOneHealthyInstanceWithContext(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, error)
+	// 获取一个健康的实例，并返回实例所在的就近选择层级：cluster（同集群）、zone（同可用区）或 all（全部实例）
+	SelectOneHealthyInstanceWithLocality(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, error)
+	// 获取一个健康的实例和所用的就近选择层级，请求结束后调用返回的 release，least_active 和 p2c 据此减少实例的活跃请求数
+	// 使用 least_active 和 p2c 时应通过该方法选择实例，其它方法选中后立即释放，不跟踪请求
+	SelectOneHealthyInstanceWithRelease(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, func(), error)
+	GetAllServicesInfoWithContext(ctx context.Context, param vo.GetAllServiceInfoParam) ([]model.Service, error)
+
+	// 获取本客户端注册的临时实例的心跳状态：最近一次成功的时间、连续失败次数和当前的心跳周期，实例未注册时返回 error
+	GetBeatStatus(param vo.GetBeatStatusParam) (model.BeatStatus, error)
+
+	// 获取客户端正在缓存并后台刷新的服务数；没有订阅且超过 ClientConfig.ServiceIdleTimeMs 未被读取的服务不再刷新
+	GetTrackedServiceCount() int
+
+	// 获取订阅回调的投递情况：队列深度、丢弃和合并的通知数、回调 panic 次数
+	GetDispatcherMetrics() model.DispatcherMetrics
+
+	//关闭客户端，注销临时实例并停止所有后台任务
+	Close() error
 }
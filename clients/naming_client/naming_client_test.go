@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/clients/cache"
 	"github.com/uugtv/nacos-sdk-go/clients/nacos_client"
 	"github.com/uugtv/nacos-sdk-go/common/constant"
 	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/common/loadbalancer"
 	"github.com/uugtv/nacos-sdk-go/mock"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
@@ -44,7 +47,7 @@ func Test_RegisterServiceInstance_withoutGroupeName(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("POST"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("POST"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -84,7 +87,7 @@ func Test_RegisterServiceInstance_withGroupeName(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("POST"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("POST"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -125,7 +128,7 @@ func Test_RegisterServiceInstance_withCluster(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("POST"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("POST"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -167,7 +170,7 @@ func Test_RegisterServiceInstance_401(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("POST"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("POST"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -208,7 +211,7 @@ func TestNamingProxy_DeristerService_WithoutGroupName(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("DELETE"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("DELETE"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -240,7 +243,7 @@ func TestNamingProxy_DeristerService_WithGroupName(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("DELETE"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("DELETE"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -273,7 +276,7 @@ func TestNamingProxy_DeristerService_401(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("DELETE"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("DELETE"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -372,7 +375,7 @@ func TestNamingProxy_GetService_WithoutGroupName(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("GET"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("GET"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/list"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -400,7 +403,7 @@ func TestNamingClient_SelectAllInstancs(t *testing.T) {
 	}()
 	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
 
-	mockIHttpAgent.EXPECT().Request(gomock.Eq("GET"),
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("GET"),
 		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/list"),
 		gomock.AssignableToTypeOf(http.Header{}),
 		gomock.Eq(uint64(20*1000)),
@@ -507,11 +510,11 @@ func TestNamingClient_SelectOneHealthyInstance_SameWeight(t *testing.T) {
 	nc.SetClientConfig(clientConfigTest)
 	nc.SetHttpAgent(mockIHttpAgent)
 	client, _ := NewNamingClient(&nc)
-	instance1, err := client.selectOneHealthyInstances(services)
+	instance1, err := client.selectOneHealthyInstances(services, vo.SelectOneHealthInstanceParam{})
 	fmt.Println(utils.ToJsonString(instance1))
 	assert.Nil(t, err)
 	assert.NotNil(t, instance1)
-	instance2, err := client.selectOneHealthyInstances(services)
+	instance2, err := client.selectOneHealthyInstances(services, vo.SelectOneHealthInstanceParam{})
 	fmt.Println(utils.ToJsonString(instance2))
 	assert.Nil(t, err)
 	assert.NotNil(t, instance2)
@@ -538,12 +541,43 @@ func TestNamingClient_SelectOneHealthyInstance_Empty(t *testing.T) {
 	nc.SetClientConfig(clientConfigTest)
 	nc.SetHttpAgent(mockIHttpAgent)
 	client, _ := NewNamingClient(&nc)
-	instance, err := client.selectOneHealthyInstances(services)
+	instance, err := client.selectOneHealthyInstances(services, vo.SelectOneHealthInstanceParam{})
 	fmt.Println(utils.ToJsonString(instance))
 	assert.NotNil(t, err)
 	assert.Nil(t, instance)
 }
 
+func TestNamingClient_SelectOneHealthyInstance_Release(t *testing.T) {
+	client := NamingClient{loadBalancer: loadbalancer.NewLeastActiveBalancer()}
+	service := model.Service{Name: "DEFAULT_GROUP@@DEMO", Hosts: []model.Instance{
+		{Ip: "10.0.0.1", Port: 80, Weight: 1, Enable: true, Healthy: true},
+		{Ip: "10.0.0.2", Port: 80, Weight: 1, Enable: true, Healthy: true},
+	}}
+	first, _, release, err := client.acquireOneHealthyInstance(service, vo.SelectOneHealthInstanceParam{})
+	assert.Nil(t, err)
+	second, _, _, err := client.acquireOneHealthyInstance(service, vo.SelectOneHealthInstanceParam{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.Ip, second.Ip)
+
+	// 释放后 first 的活跃请求数最少，重复释放只生效一次
+	release()
+	release()
+	instance, _, _, err := client.acquireOneHealthyInstance(service, vo.SelectOneHealthInstanceParam{})
+	assert.Nil(t, err)
+	assert.Equal(t, first.Ip, instance.Ip)
+
+	// 不跟踪请求的选择立即释放，不影响活跃请求数
+	for i := 0; i < 10; i++ {
+		_, err = client.selectOneHealthyInstances(service, vo.SelectOneHealthInstanceParam{})
+		assert.Nil(t, err)
+	}
+	instance, _, _, err = client.acquireOneHealthyInstance(service, vo.SelectOneHealthInstanceParam{})
+	assert.Nil(t, err)
+	third, _, _, err := client.acquireOneHealthyInstance(service, vo.SelectOneHealthInstanceParam{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, instance.Ip, third.Ip)
+}
+
 func TestNamingClient_SelectInstances_Healthy(t *testing.T) {
 	services := model.Service(model.Service{
 		Name:            "DEFAULT_GROUP@@DEMO",
@@ -630,7 +664,7 @@ func TestNamingClient_SelectInstances_Healthy(t *testing.T) {
 	nc.SetClientConfig(clientConfigTest)
 	nc.SetHttpAgent(mockIHttpAgent)
 	client, _ := NewNamingClient(&nc)
-	instances, err := client.selectInstances(services, true)
+	instances, err := client.selectInstances(services, vo.SelectInstancesParam{HealthyOnly: true})
 	fmt.Println(utils.ToJsonString(instances))
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(instances))
@@ -722,9 +756,13 @@ func TestNamingClient_SelectInstances_Unhealthy(t *testing.T) {
 	nc.SetClientConfig(clientConfigTest)
 	nc.SetHttpAgent(mockIHttpAgent)
 	client, _ := NewNamingClient(&nc)
-	instances, err := client.selectInstances(services, false)
+	instances, err := client.selectInstances(services, vo.SelectInstancesParam{HealthyOnly: false})
 	fmt.Println(utils.ToJsonString(instances))
 	assert.Nil(t, err)
+	assert.Equal(t, 3, len(instances))
+	instances, err = client.selectInstances(services, vo.SelectInstancesParam{
+		Filter: &vo.InstanceFilter{Health: constant.INSTANCE_HEALTH_UNHEALTHY}})
+	assert.Nil(t, err)
 	assert.Equal(t, 1, len(instances))
 }
 
@@ -748,8 +786,283 @@ func TestNamingClient_SelectInstances_Empty(t *testing.T) {
 	nc.SetClientConfig(clientConfigTest)
 	nc.SetHttpAgent(mockIHttpAgent)
 	client, _ := NewNamingClient(&nc)
-	instances, err := client.selectInstances(services, false)
+	instances, err := client.selectInstances(services, vo.SelectInstancesParam{HealthyOnly: false})
 	fmt.Println(utils.ToJsonString(instances))
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, len(instances))
 }
+
+func TestNamingClient_Close(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ctrl.Finish()
+	}()
+	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
+
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("PUT"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/beat"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).AnyTimes().
+		Return(http_agent.FakeHttpResponse(200, `{"clientBeatInterval":5000}`), nil)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("DELETE"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(map[string]string{
+			"namespaceId": "",
+			"serviceName": "DEFAULT_GROUP@@DEMO",
+			"clusterName": "a",
+			"ip":          "10.0.0.10",
+			"port":        "80",
+			"ephemeral":   "true",
+		})).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `ok`), nil)
+
+	nc := nacos_client.NacosClient{}
+	nc.SetServerConfig([]constant.ServerConfig{serverConfigTest})
+	nc.SetClientConfig(clientConfigTest)
+	nc.SetHttpAgent(mockIHttpAgent)
+	client, _ := NewNamingClient(&nc)
+	client.beatReactor.AddBeatInfo("DEFAULT_GROUP@@DEMO", model.BeatInfo{
+		Ip:          "10.0.0.10",
+		Port:        80,
+		ServiceName: "DEFAULT_GROUP@@DEMO",
+		Cluster:     "a",
+		Period:      time.Second,
+	})
+
+	err := client.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, client.beatReactor.beatMap.Count())
+	assert.Nil(t, client.Close())
+}
+
+func TestNamingClient_ReRegisterWhenInstanceNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ctrl.Finish()
+	}()
+	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
+
+	registered := make(chan map[string]string, 2)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("POST"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Any(),
+		gomock.Any()).Times(2).
+		DoAndReturn(func(ctx interface{}, method string, path string, header http.Header, timeoutMs uint64, params map[string]string) (*http.Response, error) {
+			registered <- params
+			return http_agent.FakeHttpResponse(200, `ok`), nil
+		})
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("PUT"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/beat"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `{"clientBeatInterval":5000,"code":20404,"lightBeatEnabled":true}`), nil)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("DELETE"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `ok`), nil)
+
+	nc := nacos_client.NacosClient{}
+	nc.SetServerConfig([]constant.ServerConfig{serverConfigTest})
+	nc.SetClientConfig(clientConfigTest)
+	nc.SetHttpAgent(mockIHttpAgent)
+	client, _ := NewNamingClient(&nc)
+	events := make(chan model.InstanceEvent, 1)
+	success, err := client.RegisterInstance(vo.RegisterInstanceParam{
+		ServiceName: "DEMO",
+		Ip:          "10.0.0.10",
+		Port:        80,
+		Weight:      2,
+		Enable:      true,
+		Healthy:     true,
+		Metadata:    map[string]string{"version": "1"},
+		ClusterName: "a",
+		Ephemeral:   true,
+		OnEvent: func(event model.InstanceEvent) {
+			events <- event
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, success)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, constant.INSTANCE_EVENT_REREGISTERED, event.Type)
+		assert.Equal(t, "DEMO", event.ServiceName)
+		assert.Equal(t, "DEFAULT_GROUP", event.GroupName)
+		assert.Equal(t, "10.0.0.10", event.Ip)
+		assert.Equal(t, uint64(80), event.Port)
+		assert.Nil(t, event.Err)
+	case <-time.After(time.Second):
+		t.Fatal("expect reregistered event")
+	}
+	first, second := <-registered, <-registered
+	assert.Equal(t, first, second)
+	assert.Equal(t, `{"version":"1"}`, second["metadata"])
+
+	status, err := client.GetBeatStatus(vo.GetBeatStatusParam{ServiceName: "DEMO", Ip: "10.0.0.10", Port: 80})
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, status.Period)
+	_, err = client.GetBeatStatus(vo.GetBeatStatusParam{ServiceName: "DEMO", Ip: "10.0.0.10", Port: 81})
+	assert.NotNil(t, err)
+	assert.Nil(t, client.Close())
+}
+
+func TestNamingProxy_SendBeat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ctrl.Finish()
+	}()
+	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
+
+	beatInfo := model.BeatInfo{
+		Ip:          "10.0.0.10",
+		Port:        80,
+		ServiceName: "DEFAULT_GROUP@@DEMO",
+		Cluster:     "a",
+		Metadata:    map[string]string{"version": "1"},
+	}
+	params := map[string]string{
+		"namespaceId": "",
+		"serviceName": "DEFAULT_GROUP@@DEMO",
+		"clusterName": "a",
+		"ip":          "10.0.0.10",
+		"port":        "80",
+	}
+	fullParams := map[string]string{"beat": utils.ToJsonString(beatInfo)}
+	for k, v := range params {
+		fullParams[k] = v
+	}
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("PUT"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/beat"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(fullParams)).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `{"clientBeatInterval":5000,"code":10200,"lightBeatEnabled":true}`), nil)
+	// 轻量心跳不携带实例信息
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("PUT"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/beat"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(params)).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `{"clientBeatInterval":5000,"code":20404,"lightBeatEnabled":true}`), nil)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("POST"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `ok`), nil)
+
+	proxy, err := NewNamingProxy(clientConfigTest, []constant.ServerConfig{serverConfigTest}, mockIHttpAgent)
+	assert.Nil(t, err)
+	beatResult, err := proxy.SendBeat(beatInfo)
+	assert.Nil(t, err)
+	assert.Equal(t, model.BeatResult{ClientBeatInterval: 5000, Code: 10200, LightBeatEnabled: true}, beatResult)
+
+	// 服务端没有该实例时重新注册，下一次心跳携带完整的实例信息
+	br := BeatReactor{serviceProxy: proxy, registerParamMap: cache.NewConcurrentMap()}
+	beatInfo.LightBeatEnabled = true
+	beatResult, err = br.sendBeat(buildKey(beatInfo.ServiceName, beatInfo.Ip, beatInfo.Port), beatInfo)
+	assert.Nil(t, err)
+	assert.Equal(t, constant.RESOURCE_NOT_FOUND_CODE, beatResult.Code)
+	assert.False(t, beatResult.LightBeatEnabled)
+}
+
+func TestNamingClient_UpdateInstance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ctrl.Finish()
+	}()
+	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
+
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("POST"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `ok`), nil)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("PUT"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/beat"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).AnyTimes().
+		Return(http_agent.FakeHttpResponse(200, `{"clientBeatInterval":5000,"code":10200}`), nil)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("PUT"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Eq(map[string]string{
+			"namespaceId": "",
+			"serviceName": "DEFAULT_GROUP@@DEMO",
+			"groupName":   "DEFAULT_GROUP",
+			"clusterName": "b",
+			"ip":          "10.0.0.10",
+			"port":        "80",
+			"weight":      "0.5",
+			"enabled":     "false",
+			"metadata":    `{"version":"2"}`,
+			"ephemeral":   "false",
+		})).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `ok`), nil)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq("DELETE"),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).Times(1).
+		Return(http_agent.FakeHttpResponse(200, `ok`), nil)
+
+	nc := nacos_client.NacosClient{}
+	nc.SetServerConfig([]constant.ServerConfig{serverConfigTest})
+	nc.SetClientConfig(clientConfigTest)
+	nc.SetHttpAgent(mockIHttpAgent)
+	client, _ := NewNamingClient(&nc)
+	success, err := client.RegisterInstance(vo.RegisterInstanceParam{
+		ServiceName: "DEMO",
+		Ip:          "10.0.0.10",
+		Port:        80,
+		Weight:      1,
+		Enable:      true,
+		Healthy:     true,
+		Metadata:    map[string]string{"version": "1"},
+		ClusterName: "a",
+		Ephemeral:   true,
+	})
+	assert.Nil(t, err)
+	assert.True(t, success)
+
+	// 没有指定 Enable 时保持注册时的值；没有指定 Ephemeral 时同样更新心跳信息
+	assert.True(t, client.beatReactor.enableOf("DEFAULT_GROUP@@DEMO", vo.UpdateInstanceParam{Ip: "10.0.0.10", Port: 80}))
+	enable := false
+	success, err = client.UpdateInstance(vo.UpdateInstanceParam{
+		ServiceName: "DEMO",
+		Ip:          "10.0.0.10",
+		Port:        80,
+		Weight:      0.5,
+		Enable:      &enable,
+		Metadata:    map[string]string{"version": "2"},
+		ClusterName: "b",
+	})
+	assert.Nil(t, err)
+	assert.True(t, success)
+
+	// 之后的心跳和重新注册使用更新后的信息
+	key := buildKey("DEFAULT_GROUP@@DEMO", "10.0.0.10", 80)
+	data, _ := client.beatReactor.beatMap.Get(key)
+	beatInfo := client.beatReactor.scheduler.snapshot(data.(*model.BeatInfo))
+	assert.Equal(t, 0.5, beatInfo.Weight)
+	assert.Equal(t, map[string]string{"version": "2"}, beatInfo.Metadata)
+	assert.Equal(t, "b", beatInfo.Cluster)
+	data, _ = client.beatReactor.registerParamMap.Get(key)
+	assert.Equal(t, 0.5, data.(vo.RegisterInstanceParam).Weight)
+	assert.False(t, data.(vo.RegisterInstanceParam).Enable)
+	assert.Equal(t, "b", data.(vo.RegisterInstanceParam).ClusterName)
+	assert.Equal(t, map[string]string{"version": "2"}, data.(vo.RegisterInstanceParam).Metadata)
+	assert.False(t, client.beatReactor.enableOf("DEFAULT_GROUP@@DEMO", vo.UpdateInstanceParam{Ip: "10.0.0.10", Port: 80}))
+	assert.Nil(t, client.Close())
+}
@@ -1,6 +1,8 @@
 package naming_client
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,6 +11,7 @@ import (
 	"github.com/buger/jsonparser"
 	"github.com/uugtv/nacos-sdk-go/common/constant"
 	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/common/nacos_server"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
@@ -19,8 +22,8 @@ type NamingProxy struct {
 	nacosServer  nacos_server.NacosServer
 }
 
-func NewNamingProxy(clientCfg constant.ClientConfig, serverCfgs []constant.ServerConfig, httpAgent http_agent.IHttpAgent) (NamingProxy, error) {
-	srvProxy := NamingProxy{}
+func NewNamingProxy(clientCfg constant.ClientConfig, serverCfgs []constant.ServerConfig, httpAgent http_agent.IHttpAgent) (*NamingProxy, error) {
+	srvProxy := &NamingProxy{}
 	srvProxy.clientConfig = clientCfg
 	var err error
 	srvProxy.nacosServer, err = nacos_server.NewNacosServer(serverCfgs, httpAgent, clientCfg.TimeoutMs, clientCfg.Endpoint)
@@ -30,8 +33,8 @@ func NewNamingProxy(clientCfg constant.ClientConfig, serverCfgs []constant.Serve
 	return srvProxy, nil
 }
 
-func (proxy *NamingProxy) RegisterInstance(serviceName string, groupName string, instance model.Instance) (string, error) {
-	// log.Printf("[INFO] register instance namespaceId:<%s>,serviceName:<%s> with instance:<%s> \n", proxy.clientConfig.NamespaceId, serviceName, utils.ToJsonString(instance))
+func (proxy *NamingProxy) RegisterInstance(ctx context.Context, serviceName string, groupName string, instance model.Instance) (string, error) {
+	logger.Info("[NamingProxy] register instance", logger.F("namespaceId", proxy.clientConfig.NamespaceId), logger.F("serviceName", serviceName), logger.F("instance", utils.ToJsonString(instance)))
 	params := map[string]string{}
 	params["namespaceId"] = proxy.clientConfig.NamespaceId
 	params["serviceName"] = serviceName
@@ -44,11 +47,12 @@ func (proxy *NamingProxy) RegisterInstance(serviceName string, groupName string,
 	params["healthy"] = strconv.FormatBool(instance.Healthy)
 	params["metadata"] = utils.ToJsonString(instance.Metadata)
 	params["ephemeral"] = strconv.FormatBool(instance.Ephemeral)
-	return proxy.nacosServer.ReqApi(constant.SERVICE_PATH, params, http.MethodPost)
+	return proxy.nacosServer.ReqApiWithContext(ctx, constant.SERVICE_PATH, params, http.MethodPost)
 }
 
-func (proxy *NamingProxy) DeregisterInstance(serviceName string, ip string, port uint64, clusterName string, ephemeral bool) (string, error) {
-	// log.Printf("[INFO] deregister instance namespaceId:<%s>,serviceName:<%s> with instance:<%s:%d@%s> \n", proxy.clientConfig.NamespaceId, serviceName, ip, port, clusterName)
+func (proxy *NamingProxy) DeregisterInstance(ctx context.Context, serviceName string, ip string, port uint64, clusterName string, ephemeral bool) (string, error) {
+	logger.Info("[NamingProxy] deregister instance", logger.F("namespaceId", proxy.clientConfig.NamespaceId), logger.F("serviceName", serviceName),
+		logger.F("ip", ip), logger.F("port", port), logger.F("clusterName", clusterName))
 	params := map[string]string{}
 	params["namespaceId"] = proxy.clientConfig.NamespaceId
 	params["serviceName"] = serviceName
@@ -56,30 +60,50 @@ func (proxy *NamingProxy) DeregisterInstance(serviceName string, ip string, port
 	params["ip"] = ip
 	params["port"] = strconv.Itoa(int(port))
 	params["ephemeral"] = strconv.FormatBool(ephemeral)
-	return proxy.nacosServer.ReqApi(constant.SERVICE_PATH, params, http.MethodDelete)
+	return proxy.nacosServer.ReqApiWithContext(ctx, constant.SERVICE_PATH, params, http.MethodDelete)
 }
 
-func (proxy *NamingProxy) SendBeat(info model.BeatInfo) (int64, error) {
-	// log.Printf("[INFO] namespaceId:<%s> sending beat to server:<%s> \n", proxy.clientConfig.NamespaceId, utils.ToJsonString(info))
+func (proxy *NamingProxy) UpdateInstance(ctx context.Context, serviceName string, groupName string, instance model.Instance) (string, error) {
+	logger.Info("[NamingProxy] update instance", logger.F("namespaceId", proxy.clientConfig.NamespaceId), logger.F("serviceName", serviceName), logger.F("instance", utils.ToJsonString(instance)))
+	params := map[string]string{}
+	params["namespaceId"] = proxy.clientConfig.NamespaceId
+	params["serviceName"] = serviceName
+	params["groupName"] = groupName
+	params["clusterName"] = instance.ClusterName
+	params["ip"] = instance.Ip
+	params["port"] = strconv.Itoa(int(instance.Port))
+	params["weight"] = strconv.FormatFloat(instance.Weight, 'f', -1, 64)
+	params["enabled"] = strconv.FormatBool(instance.Enable)
+	params["metadata"] = utils.ToJsonString(instance.Metadata)
+	params["ephemeral"] = strconv.FormatBool(instance.Ephemeral)
+	return proxy.nacosServer.ReqApiWithContext(ctx, constant.SERVICE_PATH, params, http.MethodPut)
+}
+
+func (proxy *NamingProxy) SendBeat(info model.BeatInfo) (model.BeatResult, error) {
+	logger.Debug("[NamingProxy] sending beat to server", logger.F("namespaceId", proxy.clientConfig.NamespaceId), logger.F("beat", utils.ToJsonString(info)))
 	params := map[string]string{}
 	params["namespaceId"] = proxy.clientConfig.NamespaceId
 	params["serviceName"] = info.ServiceName
-	params["beat"] = utils.ToJsonString(info)
+	params["clusterName"] = info.Cluster
+	params["ip"] = info.Ip
+	params["port"] = strconv.Itoa(int(info.Port))
+	// 轻量心跳只携带实例的标识，服务端使用注册时保存的实例信息
+	if !info.LightBeatEnabled {
+		params["beat"] = utils.ToJsonString(info)
+	}
 	api := constant.SERVICE_BASE_PATH + "/instance/beat"
+	var beatResult model.BeatResult
 	result, err := proxy.nacosServer.ReqApi(api, params, http.MethodPut)
 	if err != nil {
-		return 0, err
+		return beatResult, err
 	}
 	if result != "" {
-		interVal, err := jsonparser.GetInt([]byte(result), "clientBeatInterval")
+		err = json.Unmarshal([]byte(result), &beatResult)
 		if err != nil {
-			return 0, errors.New(fmt.Sprintf("[ERROR] namespaceId:<%s> sending beat to server:<%s> get 'clientBeatInterval' from <%s> error:<%s>", proxy.clientConfig.NamespaceId, utils.ToJsonString(info), result, err.Error()))
-		} else {
-			return interVal, nil
+			return beatResult, errors.New(fmt.Sprintf("[ERROR] namespaceId:<%s> sending beat to server:<%s> parse beat result <%s> error:<%s>", proxy.clientConfig.NamespaceId, utils.ToJsonString(info), result, err.Error()))
 		}
 	}
-	return 0, nil
-
+	return beatResult, nil
 }
 
 func (proxy *NamingProxy) GetServiceList(pageNo int, pageSize int, groupName string, selector *model.ExpressionSelector) (*model.ServiceList, error) {
@@ -91,7 +115,7 @@ func (proxy *NamingProxy) GetServiceList(pageNo int, pageSize int, groupName str
 
 	if selector != nil {
 		switch selector.Type {
-		case "label":
+		case constant.SELECTOR_TYPE_LABEL:
 			params["selector"] = utils.ToJsonString(selector)
 			break
 		default:
@@ -130,13 +154,13 @@ func (proxy *NamingProxy) ServerHealthy() bool {
 	api := constant.SERVICE_BASE_PATH + "/operator/metrics"
 	result, err := proxy.nacosServer.ReqApi(api, map[string]string{}, http.MethodGet)
 	if err != nil {
-		// log.Printf("[ERROR]:namespaceId:[%s] sending server healthy failed!,result:%s error:%s", proxy.clientConfig.NamespaceId, result, err.Error())
+		logger.Error("[NamingProxy] sending server healthy failed", logger.F("namespaceId", proxy.clientConfig.NamespaceId), logger.F("result", result), logger.Err(err))
 		return false
 	}
 	if result != "" {
 		status, err := jsonparser.GetString([]byte(result), "status")
 		if err != nil {
-			// log.Printf("[ERROR]:namespaceId:[%s] sending server healthy failed!,result:%s error:%s", proxy.clientConfig.NamespaceId, result, err.Error())
+			logger.Error("[NamingProxy] sending server healthy failed", logger.F("namespaceId", proxy.clientConfig.NamespaceId), logger.F("result", result), logger.Err(err))
 		} else {
 			return status == "UP"
 		}
@@ -144,7 +168,7 @@ func (proxy *NamingProxy) ServerHealthy() bool {
 	return false
 }
 
-func (proxy *NamingProxy) QueryList(serviceName string, clusters string, udpPort int, healthyOnly bool) (string, error) {
+func (proxy *NamingProxy) QueryList(ctx context.Context, serviceName string, clusters string, udpPort int, healthyOnly bool) (string, error) {
 	param := make(map[string]string)
 	param["namespaceId"] = proxy.clientConfig.NamespaceId
 	param["serviceName"] = serviceName
@@ -153,14 +177,14 @@ func (proxy *NamingProxy) QueryList(serviceName string, clusters string, udpPort
 	param["healthyOnly"] = strconv.FormatBool(healthyOnly)
 	param["clientIp"] = utils.LocalIP()
 	api := constant.SERVICE_PATH + "/list"
-	return proxy.nacosServer.ReqApi(api, param, http.MethodGet)
+	return proxy.nacosServer.ReqApiWithContext(ctx, api, param, http.MethodGet)
 }
 
-func (proxy *NamingProxy) GetAllServiceInfoList(namespace string, groupName string, clusters string) (string, error) {
+func (proxy *NamingProxy) GetAllServiceInfoList(ctx context.Context, namespace string, groupName string, clusters string) (string, error) {
 	param := make(map[string]string)
 	param["namespaceId"] = proxy.clientConfig.NamespaceId
 	param["clusters"] = clusters
 	param["groupName"] = groupName
 	api := constant.SERVICE_INFO_PATH + "/getAll"
-	return proxy.nacosServer.ReqApi(api, param, http.MethodGet)
+	return proxy.nacosServer.ReqApiWithContext(ctx, api, param, http.MethodGet)
 }
@@ -6,8 +6,10 @@ import (
 	"net"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/utils"
 )
 
@@ -15,6 +17,9 @@ type PushReceiver struct {
 	port        int
 	host        string
 	hostReactor *HostReactor
+	mutex       sync.Mutex
+	conn        *net.UDPConn
+	closed      bool
 }
 
 type PushData struct {
@@ -27,6 +32,7 @@ func NewPushRecevier(hostReactor *HostReactor) *PushReceiver {
 	pr := PushReceiver{
 		hostReactor: hostReactor,
 	}
+	pr.conn = pr.listen()
 	go pr.startServer()
 	return &pr
 }
@@ -34,20 +40,20 @@ func NewPushRecevier(hostReactor *HostReactor) *PushReceiver {
 func (us *PushReceiver) tryListen() (*net.UDPConn, bool) {
 	addr, err := net.ResolveUDPAddr("udp", us.host+":"+strconv.Itoa(us.port))
 	if err != nil {
-		// log.Printf("[ERROR]: Can't resolve address,err: %s \n", err.Error())
+		logger.Error("[PushReceiver] can't resolve address", logger.Err(err))
 		return nil, false
 	}
 
 	conn, err := net.ListenUDP("udp", addr)
 	if err != nil {
-		// log.Printf("Error listening %s:%d,err:%s \n", us.host, us.port, err.Error())
+		logger.Error("[PushReceiver] error listening", logger.F("host", us.host), logger.F("port", us.port), logger.Err(err))
 		return nil, false
 	}
 
 	return conn, true
 }
 
-func (us *PushReceiver) startServer() {
+func (us *PushReceiver) listen() *net.UDPConn {
 	var conn *net.UDPConn
 
 	for i := 0; i < 3; i++ {
@@ -58,38 +64,60 @@ func (us *PushReceiver) startServer() {
 
 		if ok {
 			conn = conn1
-			// log.Println("[INFO] udp server start, port: " + strconv.Itoa(port))
+			logger.Info("[PushReceiver] udp server start", logger.F("port", port))
 			break
 		}
 
 		if !ok && i == 2 {
-			// log.Panicf("failed to start udp server after trying 3 times.")
+			logger.Error("[PushReceiver] failed to start udp server after trying 3 times")
 			os.Exit(1)
 		}
 	}
+	return conn
+}
 
+func (us *PushReceiver) startServer() {
+	conn := us.conn
 	defer conn.Close()
 	for {
-		us.handleClient(conn)
+		if !us.handleClient(conn) && us.isClosed() {
+			return
+		}
 	}
 }
 
-func (us *PushReceiver) handleClient(conn *net.UDPConn) {
+func (us *PushReceiver) isClosed() bool {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+	return us.closed
+}
+
+// 关闭 udp 监听，startServer 在读取失败后退出
+func (us *PushReceiver) Close() {
+	us.mutex.Lock()
+	defer us.mutex.Unlock()
+	us.closed = true
+	us.conn.Close()
+}
+
+func (us *PushReceiver) handleClient(conn *net.UDPConn) bool {
 	data := make([]byte, 4024)
 	n, remoteAddr, err := conn.ReadFromUDP(data)
 	if err != nil {
-		// log.Printf("[ERROR]:failed to read UDP msg because of %s \n", err.Error())
-		return
+		if !us.isClosed() {
+			logger.Error("[PushReceiver] failed to read UDP msg", logger.Err(err))
+		}
+		return false
 	}
 
 	s := utils.TryDecompressData(data[:n])
-	// log.Println("[INFO] receive push: "+s+" from: ", remoteAddr)
+	logger.Debug("[PushReceiver] receive push", logger.F("data", s), logger.F("from", remoteAddr))
 
 	var pushData PushData
 	err1 := json.Unmarshal([]byte(s), &pushData)
 	if err1 != nil {
-		// log.Printf("[ERROR] failed to process push data.err:%s \n", err1.Error())
-		return
+		logger.Error("[PushReceiver] failed to process push data", logger.Err(err1))
+		return true
 	}
 	ack := make(map[string]string)
 
@@ -112,4 +140,5 @@ func (us *PushReceiver) handleClient(conn *net.UDPConn) {
 
 	bs, _ := json.Marshal(ack)
 	conn.WriteToUDP(bs, remoteAddr)
+	return true
 }
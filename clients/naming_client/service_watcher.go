@@ -0,0 +1,69 @@
+package naming_client
+
+import (
+	"context"
+
+	"github.com/uugtv/nacos-sdk-go/common/watcher"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+// serviceWatcher 将服务变更事件写入 channel，channel 满时不阻塞 dispatcher 的执行线程，由 dispatcher 稍后重试
+type serviceWatcher struct {
+	guard  *watcher.Guard
+	events chan model.ServiceEvent
+}
+
+func newServiceWatcher() *serviceWatcher {
+	return &serviceWatcher{guard: watcher.NewGuard(), events: make(chan model.ServiceEvent, watcher.Default_Watch_Chan_Size)}
+}
+
+// offer 尝试写入事件，channel 已满时返回 false；监听取消后事件直接丢弃
+func (w *serviceWatcher) offer(event model.ServiceEvent) bool {
+	accepted := true
+	w.guard.Send(func(done <-chan struct{}) {
+		select {
+		case w.events <- event:
+		default:
+			accepted = false
+		}
+	})
+	return accepted
+}
+
+func (w *serviceWatcher) send(event model.ServiceEvent) {
+	w.offer(event)
+}
+
+func (w *serviceWatcher) close() {
+	w.guard.Close(func() {
+		close(w.events)
+	})
+}
+
+// Watch 监听服务，变更以事件的形式写入返回的 channel，第一个事件为订阅时的全量快照
+// ctx 取消或客户端关闭后取消监听并关闭 channel；param 中的 SubscribeCallback 和 OnEvent 会被忽略
+// 读取 channel 不及时时，积压的事件按 ClientConfig.CallbackOverflowPolicy 处理
+func (sc *NamingClient) Watch(ctx context.Context, param vo.SubscribeParam) (<-chan model.ServiceEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	serviceWatcher := newServiceWatcher()
+	param.SubscribeCallback = nil
+	param.OnEvent = serviceWatcher.send
+	sc.subCallback.addEventOffer(&param.OnEvent, serviceWatcher.offer)
+	subscription, err := sc.Subscribe(&param)
+	if err != nil {
+		sc.subCallback.offers.Delete(&param.OnEvent)
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sc.hostReactor.done:
+		}
+		subscription.Unsubscribe()
+		serviceWatcher.close()
+	}()
+	return serviceWatcher.events, nil
+}
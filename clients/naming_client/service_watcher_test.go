@@ -0,0 +1,89 @@
+package naming_client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/clients/nacos_client"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/mock"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+func receiveServiceEvent(t *testing.T, events <-chan model.ServiceEvent) model.ServiceEvent {
+	select {
+	case event, ok := <-events:
+		assert.True(t, ok)
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("service event not received")
+	}
+	return model.ServiceEvent{}
+}
+
+func TestNamingClient_Watch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/list"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).AnyTimes().
+		Return(http_agent.FakeHttpResponse(200, `{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,`+
+			`"hosts":[{"ip":"10.0.0.1","port":80,"weight":1,"healthy":true,"enabled":true}]}`), nil)
+
+	nc := nacos_client.NacosClient{}
+	nc.SetServerConfig([]constant.ServerConfig{serverConfigTest})
+	nc.SetClientConfig(clientConfigTest)
+	nc.SetHttpAgent(mockIHttpAgent)
+	client, _ := NewNamingClient(&nc)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := client.Watch(ctx, vo.SubscribeParam{ServiceName: "DEMO", Clusters: []string{"a"}})
+	assert.Nil(t, err)
+	event := receiveServiceEvent(t, events)
+	assert.Equal(t, 1, len(event.Added))
+	assert.Equal(t, "10.0.0.1", event.Added[0].Ip)
+
+	client.hostReactor.ProcessServiceJson(`{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,` +
+		`"hosts":[{"ip":"10.0.0.1","port":80,"weight":1},{"ip":"10.0.0.2","port":80,"weight":1}]}`)
+	event = receiveServiceEvent(t, events)
+	assert.Equal(t, 1, len(event.Added))
+	assert.Equal(t, "10.0.0.2", event.Added[0].Ip)
+	assert.Equal(t, 2, len(event.Service.Hosts))
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel should be closed after ctx is cancelled")
+	}
+	funcs, _ := client.subCallback.eventFuncsMap.Get("DEFAULT_GROUP@@DEMO@@a")
+	assert.Equal(t, 0, len(funcs.([]*func(event model.ServiceEvent))))
+
+	_, err = client.Watch(ctx, vo.SubscribeParam{ServiceName: "DEMO"})
+	assert.NotNil(t, err)
+}
+
+func TestServiceWatcher_Offer(t *testing.T) {
+	w := newServiceWatcher()
+	for i := 0; i < cap(w.events); i++ {
+		assert.True(t, w.offer(model.ServiceEvent{}))
+	}
+	// channel 已满时不阻塞
+	assert.False(t, w.offer(model.ServiceEvent{}))
+	<-w.events
+	assert.True(t, w.offer(model.ServiceEvent{}))
+
+	w.close()
+	assert.True(t, w.offer(model.ServiceEvent{}))
+}
@@ -2,24 +2,52 @@ package naming_client
 
 import (
 	"errors"
+	"reflect"
+	"strconv"
+	"sync"
 
 	"github.com/uugtv/nacos-sdk-go/clients/cache"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/model"
 	"github.com/uugtv/nacos-sdk-go/utils"
 )
 
 type SubscribeCallback struct {
 	callbackFuncsMap cache.ConcurrentMap
+	eventFuncsMap    cache.ConcurrentMap
+	// 回调函数指针 -> *instanceSelector，订阅时指定了选择器的回调只收到匹配的实例
+	selectors *sync.Map
+	// 事件监听指针 -> func(model.ServiceEvent) bool，以不阻塞的方式投递事件，返回 false 时由 dispatcher 稍后重试
+	offers *sync.Map
+	// 回调在 dispatcher 的线程中异步执行，每个回调有独立的有序队列
+	dispatcher *EventDispatcher
 }
 
 func NewSubscribeCallback() SubscribeCallback {
+	return NewSubscribeCallbackWithDispatcher(NewEventDispatcher(Default_Callback_Thread_Num, Default_Callback_Queue_Size,
+		constant.DISPATCH_POLICY_COALESCE))
+}
+
+func NewSubscribeCallbackWithDispatcher(dispatcher *EventDispatcher) SubscribeCallback {
 	ed := SubscribeCallback{}
 	ed.callbackFuncsMap = cache.NewConcurrentMap()
+	ed.eventFuncsMap = cache.NewConcurrentMap()
+	ed.selectors = &sync.Map{}
+	ed.offers = &sync.Map{}
+	ed.dispatcher = dispatcher
 	return ed
 }
 
+func (ed *SubscribeCallback) addCallbackFuncsWithSelector(serviceName string, clusters string, callbackFunc *func(services []model.SubscribeService, err error), selector *instanceSelector) {
+	if selector != nil {
+		ed.selectors.Store(callbackFunc, selector)
+	}
+	ed.AddCallbackFuncs(serviceName, clusters, callbackFunc)
+}
+
 func (ed *SubscribeCallback) AddCallbackFuncs(serviceName string, clusters string, callbackFunc *func(services []model.SubscribeService, err error)) {
-	// log.Printf("[INFO] adding " + serviceName + " with " + clusters + " to listener map")
+	logger.Info("[SubscribeCallback] adding to listener map", logger.F("serviceName", serviceName), logger.F("clusters", clusters))
 	key := utils.GetServiceCacheKey(serviceName, clusters)
 	var funcs []*func(services []model.SubscribeService, err error)
 	old, ok := ed.callbackFuncsMap.Get(key)
@@ -28,10 +56,11 @@ func (ed *SubscribeCallback) AddCallbackFuncs(serviceName string, clusters strin
 	}
 	funcs = append(funcs, callbackFunc)
 	ed.callbackFuncsMap.Set(key, funcs)
+	ed.dispatcher.add(callbackFunc, key, ed.callbackDeliver(callbackFunc))
 }
 
 func (ed *SubscribeCallback) RemoveCallbackFuncs(serviceName string, clusters string, callbackFunc *func(services []model.SubscribeService, err error)) {
-	// log.Printf("[INFO] removing " + serviceName + " with " + clusters + " to listener map")
+	logger.Info("[SubscribeCallback] removing from listener map", logger.F("serviceName", serviceName), logger.F("clusters", clusters))
 	key := utils.GetServiceCacheKey(serviceName, clusters)
 	funcs, ok := ed.callbackFuncsMap.Get(key)
 	if ok && funcs != nil {
@@ -43,36 +72,172 @@ func (ed *SubscribeCallback) RemoveCallbackFuncs(serviceName string, clusters st
 		}
 		ed.callbackFuncsMap.Set(key, newFuncs)
 	}
+	ed.selectors.Delete(callbackFunc)
+	ed.dispatcher.remove(callbackFunc)
+
+}
 
+func (ed *SubscribeCallback) addEventFuncsWithSelector(serviceName string, clusters string, eventFunc *func(event model.ServiceEvent), selector *instanceSelector) {
+	if selector != nil {
+		ed.selectors.Store(eventFunc, selector)
+	}
+	ed.AddEventFuncs(serviceName, clusters, eventFunc)
+}
+
+// addEventOffer 为事件监听设置不阻塞的投递函数，需要在添加监听之前设置
+func (ed *SubscribeCallback) addEventOffer(eventFunc *func(event model.ServiceEvent), offer func(event model.ServiceEvent) bool) {
+	ed.offers.Store(eventFunc, offer)
+}
+
+func (ed *SubscribeCallback) AddEventFuncs(serviceName string, clusters string, eventFunc *func(event model.ServiceEvent)) {
+	logger.Info("[SubscribeCallback] adding to event listener map", logger.F("serviceName", serviceName), logger.F("clusters", clusters))
+	key := utils.GetServiceCacheKey(serviceName, clusters)
+	var funcs []*func(event model.ServiceEvent)
+	old, ok := ed.eventFuncsMap.Get(key)
+	if ok {
+		funcs = append(funcs, old.([]*func(event model.ServiceEvent))...)
+	}
+	funcs = append(funcs, eventFunc)
+	ed.eventFuncsMap.Set(key, funcs)
+	ed.dispatcher.add(eventFunc, key, ed.eventDeliver(eventFunc))
+}
+
+func (ed *SubscribeCallback) RemoveEventFuncs(serviceName string, clusters string, eventFunc *func(event model.ServiceEvent)) {
+	logger.Info("[SubscribeCallback] removing from event listener map", logger.F("serviceName", serviceName), logger.F("clusters", clusters))
+	key := utils.GetServiceCacheKey(serviceName, clusters)
+	funcs, ok := ed.eventFuncsMap.Get(key)
+	if ok && funcs != nil {
+		var newFuncs []*func(event model.ServiceEvent)
+		for _, funcItem := range funcs.([]*func(event model.ServiceEvent)) {
+			if funcItem != eventFunc {
+				newFuncs = append(newFuncs, funcItem)
+			}
+		}
+		ed.eventFuncsMap.Set(key, newFuncs)
+	}
+	ed.selectors.Delete(eventFunc)
+	ed.offers.Delete(eventFunc)
+	ed.dispatcher.remove(eventFunc)
+}
+
+// hasListeners 服务是否还有监听
+func (ed *SubscribeCallback) hasListeners(serviceName string, clusters string) bool {
+	key := utils.GetServiceCacheKey(serviceName, clusters)
+	if funcs, ok := ed.callbackFuncsMap.Get(key); ok && len(funcs.([]*func(services []model.SubscribeService, err error))) > 0 {
+		return true
+	}
+	if funcs, ok := ed.eventFuncsMap.Get(key); ok && len(funcs.([]*func(event model.ServiceEvent))) > 0 {
+		return true
+	}
+	return false
 }
 
 func (ed *SubscribeCallback) ServiceChanged(service *model.Service) {
+	ed.serviceChanged(nil, service)
+}
+
+// serviceChanged 通知服务变更，oldService 为变更前的服务（首次获取时为空），用于计算实例变化
+// 每个监听各自排队异步通知，实例列表为空时同样会通知
+func (ed *SubscribeCallback) serviceChanged(oldService *model.Service, service *model.Service) {
 	if service == nil || service.Name == "" {
 		return
 	}
 	key := utils.GetServiceCacheKey(service.Name, service.Clusters)
+	task := dispatchTask{oldService: oldService, service: service}
 	funcs, ok := ed.callbackFuncsMap.Get(key)
 	if ok {
 		for _, funcItem := range funcs.([]*func(services []model.SubscribeService, err error)) {
-			var subscribeServices []model.SubscribeService
-			if len(service.Hosts) == 0 {
-				(*funcItem)(subscribeServices, errors.New("[client.Subscribe] subscribe failed,hosts is empty"))
-				return
-			}
-			for _, host := range service.Hosts {
-				var subscribeService model.SubscribeService
-				subscribeService.Valid = host.Valid
-				subscribeService.Port = host.Port
-				subscribeService.Ip = host.Ip
-				subscribeService.Metadata = host.Metadata
-				subscribeService.ServiceName = host.ServiceName
-				subscribeService.ClusterName = host.ClusterName
-				subscribeService.Weight = host.Weight
-				subscribeService.InstanceId = host.InstanceId
-				subscribeService.Enable = host.Enable
-				subscribeServices = append(subscribeServices, subscribeService)
+			ed.dispatcher.dispatch(funcItem, task)
+		}
+	}
+	eventFuncs, ok := ed.eventFuncsMap.Get(key)
+	if ok {
+		for _, funcItem := range eventFuncs.([]*func(event model.ServiceEvent)) {
+			ed.dispatcher.dispatch(funcItem, task)
+		}
+	}
+}
+
+// notifyEvent 只通知一个事件监听，与其它通知在同一队列中保持顺序
+func (ed *SubscribeCallback) notifyEvent(eventFunc *func(event model.ServiceEvent), oldService *model.Service, service *model.Service) {
+	ed.dispatcher.dispatch(eventFunc, dispatchTask{oldService: oldService, service: service})
+}
+
+func (ed *SubscribeCallback) callbackDeliver(funcItem *func(services []model.SubscribeService, err error)) func(task dispatchTask) bool {
+	return func(task dispatchTask) bool {
+		var subscribeServices []model.SubscribeService
+		hosts := task.service.Hosts
+		if selector, ok := ed.selectors.Load(funcItem); ok {
+			hosts = selector.(*instanceSelector).filter(hosts)
+		}
+		if len(hosts) == 0 {
+			(*funcItem)(subscribeServices, errors.New("[client.Subscribe] subscribe failed,hosts is empty"))
+			return true
+		}
+		for _, host := range hosts {
+			var subscribeService model.SubscribeService
+			subscribeService.Valid = host.Valid
+			subscribeService.Port = host.Port
+			subscribeService.Ip = host.Ip
+			subscribeService.Metadata = host.Metadata
+			subscribeService.ServiceName = host.ServiceName
+			subscribeService.ClusterName = host.ClusterName
+			subscribeService.Weight = host.Weight
+			subscribeService.InstanceId = host.InstanceId
+			subscribeService.Enable = host.Enable
+			subscribeServices = append(subscribeServices, subscribeService)
+		}
+		(*funcItem)(subscribeServices, nil)
+		return true
+	}
+}
+
+func (ed *SubscribeCallback) eventDeliver(funcItem *func(event model.ServiceEvent)) func(task dispatchTask) bool {
+	return func(task dispatchTask) bool {
+		var selector *instanceSelector
+		if s, ok := ed.selectors.Load(funcItem); ok {
+			selector = s.(*instanceSelector)
+		}
+		event := newServiceEvent(task.oldService, task.service, selector)
+		if offer, ok := ed.offers.Load(funcItem); ok {
+			return offer.(func(event model.ServiceEvent) bool)(event)
+		}
+		(*funcItem)(event)
+		return true
+	}
+}
+
+// newServiceEvent 以 ip、端口和集群标识实例，比较变更前后的实例列表
+func newServiceEvent(oldService *model.Service, service *model.Service, selector *instanceSelector) model.ServiceEvent {
+	event := model.ServiceEvent{Service: *service}
+	event.Service.Hosts = selector.filter(service.Hosts)
+	oldHosts := map[string]model.Instance{}
+	if oldService != nil {
+		for _, host := range selector.filter(oldService.Hosts) {
+			oldHosts[serviceInstanceKey(host)] = host
+		}
+	}
+	for _, host := range event.Service.Hosts {
+		key := serviceInstanceKey(host)
+		old, ok := oldHosts[key]
+		if !ok {
+			event.Added = append(event.Added, host)
+		} else if !reflect.DeepEqual(old, host) {
+			event.Modified = append(event.Modified, host)
+		}
+		delete(oldHosts, key)
+	}
+	if oldService != nil {
+		for _, host := range selector.filter(oldService.Hosts) {
+			if _, ok := oldHosts[serviceInstanceKey(host)]; ok {
+				event.Removed = append(event.Removed, host)
 			}
-			(*funcItem)(subscribeServices, nil)
 		}
 	}
+	return event
+}
+
+func serviceInstanceKey(instance model.Instance) string {
+	return instance.Ip + constant.NAMING_INSTANCE_ID_SPLITTER + strconv.FormatUint(instance.Port, 10) +
+		constant.NAMING_INSTANCE_ID_SPLITTER + instance.ClusterName
 }
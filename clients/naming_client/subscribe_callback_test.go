@@ -99,7 +99,7 @@ func TestEventDispatcher_RemoveCallbackFuncs(t *testing.T) {
 	ed.AddCallbackFuncs(utils.GetGroupName(param2.ServiceName, param2.GroupName), strings.Join(param2.Clusters, ","), &param2.SubscribeCallback)
 	assert.Equal(t, len(ed.callbackFuncsMap.Items()), 1, "callback funcs map length should be 2")
 
-	for k, v := range ed.callbackFuncsMap.Items() {
+	for range ed.callbackFuncsMap.Items() {
 		// log.Printf("key:%s,%d", k, len(v.([]*func(services []model.SubscribeService, err error))))
 	}
 
@@ -161,3 +161,117 @@ func TestSubscribeCallback_ServiceChanged(t *testing.T) {
 
 	ed.ServiceChanged(&service)
 }
+
+func TestSubscribeCallback_ServiceChangedWithSelector(t *testing.T) {
+	service := model.Service{
+		Name:     "public@@Test",
+		Clusters: "default",
+		Hosts: []model.Instance{
+			{Ip: "127.0.0.1", Port: 8080, Metadata: map[string]string{"version": "1"}},
+			{Ip: "127.0.0.2", Port: 8080, Metadata: map[string]string{"version": "2"}},
+		},
+	}
+	ed := NewSubscribeCallback()
+	var all, selected []model.SubscribeService
+	allCallback := func(services []model.SubscribeService, err error) {
+		all = services
+	}
+	selectedCallback := func(services []model.SubscribeService, err error) {
+		selected = services
+	}
+	selector, err := newInstanceSelector(&model.ExpressionSelector{Expression: "version=2"}, nil)
+	assert.Nil(t, err)
+	ed.AddCallbackFuncs("public@@Test", "default", &allCallback)
+	ed.addCallbackFuncsWithSelector("public@@Test", "default", &selectedCallback, selector)
+
+	ed.ServiceChanged(&service)
+	assert.True(t, ed.dispatcher.waitIdle(time.Second))
+	assert.Equal(t, 2, len(all))
+	assert.Equal(t, 1, len(selected))
+	assert.Equal(t, "127.0.0.2", selected[0].Ip)
+
+	ed.RemoveCallbackFuncs("public@@Test", "default", &selectedCallback)
+	_, ok := ed.selectors.Load(&selectedCallback)
+	assert.False(t, ok)
+}
+
+func TestSubscribeCallback_ServiceChangedEmptyHosts(t *testing.T) {
+	// 单个投递线程，回调串行执行
+	ed := NewSubscribeCallbackWithDispatcher(NewEventDispatcher(1, 0, ""))
+	var errs []error
+	var events []model.ServiceEvent
+	callback1 := func(services []model.SubscribeService, err error) {
+		errs = append(errs, err)
+	}
+	callback2 := func(services []model.SubscribeService, err error) {
+		errs = append(errs, err)
+	}
+	onEvent1 := func(event model.ServiceEvent) {
+		events = append(events, event)
+	}
+	onEvent2 := func(event model.ServiceEvent) {
+		events = append(events, event)
+	}
+	ed.AddCallbackFuncs("public@@Test", "default", &callback1)
+	ed.AddCallbackFuncs("public@@Test", "default", &callback2)
+	ed.AddEventFuncs("public@@Test", "default", &onEvent1)
+	ed.AddEventFuncs("public@@Test", "default", &onEvent2)
+
+	old := model.Service{Name: "public@@Test", Clusters: "default", Hosts: []model.Instance{{Ip: "127.0.0.1", Port: 8080}}}
+	ed.serviceChanged(&old, &model.Service{Name: "public@@Test", Clusters: "default"})
+	assert.True(t, ed.dispatcher.waitIdle(time.Second))
+
+	// 每个监听都会被通知
+	assert.Equal(t, 2, len(errs))
+	assert.NotNil(t, errs[0])
+	assert.NotNil(t, errs[1])
+	assert.Equal(t, 2, len(events))
+	for _, event := range events {
+		assert.Equal(t, 0, len(event.Service.Hosts))
+		assert.Equal(t, old.Hosts, event.Removed)
+	}
+
+	ed.RemoveEventFuncs("public@@Test", "default", &onEvent1)
+	events = nil
+	ed.ServiceChanged(&old)
+	assert.True(t, ed.dispatcher.waitIdle(time.Second))
+	assert.Equal(t, 1, len(events))
+
+	// 取消前已取出的监听在取消后才投递时被丢弃，也不会重新创建队列
+	ed.dispatcher.dispatch(&onEvent1, dispatchTask{service: &old})
+	assert.True(t, ed.dispatcher.waitIdle(time.Second))
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, 3, ed.dispatcher.Metrics().Subscribers)
+}
+
+func TestNewServiceEvent(t *testing.T) {
+	oldService := model.Service{Name: "public@@Test", Hosts: []model.Instance{
+		{Ip: "127.0.0.1", Port: 8080, ClusterName: "a", Weight: 1},
+		{Ip: "127.0.0.2", Port: 8080, ClusterName: "a", Weight: 1},
+		{Ip: "127.0.0.3", Port: 8080, ClusterName: "a", Weight: 1, Metadata: map[string]string{"version": "2"}},
+	}}
+	service := model.Service{Name: "public@@Test", Hosts: []model.Instance{
+		{Ip: "127.0.0.1", Port: 8080, ClusterName: "a", Weight: 1},
+		{Ip: "127.0.0.2", Port: 8080, ClusterName: "a", Weight: 5},
+		{Ip: "127.0.0.4", Port: 8080, ClusterName: "a", Weight: 1, Metadata: map[string]string{"version": "2"}},
+	}}
+
+	event := newServiceEvent(&oldService, &service, nil)
+	assert.Equal(t, service, event.Service)
+	assert.Equal(t, []model.Instance{service.Hosts[2]}, event.Added)
+	assert.Equal(t, []model.Instance{oldService.Hosts[2]}, event.Removed)
+	assert.Equal(t, []model.Instance{service.Hosts[1]}, event.Modified)
+
+	// 首次通知时全部实例都是新增
+	event = newServiceEvent(nil, &service, nil)
+	assert.Equal(t, service.Hosts, event.Added)
+	assert.Nil(t, event.Removed)
+
+	// 按选择器过滤后再比较
+	selector, _ := newInstanceSelector(&model.ExpressionSelector{Expression: "version=2"}, nil)
+	event = newServiceEvent(&oldService, &service, selector)
+	assert.Equal(t, []model.Instance{service.Hosts[2]}, event.Service.Hosts)
+	assert.Equal(t, []model.Instance{service.Hosts[2]}, event.Added)
+	assert.Equal(t, []model.Instance{oldService.Hosts[2]}, event.Removed)
+	assert.Nil(t, event.Modified)
+}
@@ -0,0 +1,58 @@
+package naming_client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/utils"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+type subscription struct {
+	client      *NamingClient
+	param       *vo.SubscribeParam
+	selector    *instanceSelector
+	serviceName string
+	clusters    string
+	mutex       sync.Mutex
+	cancelled   bool
+}
+
+func (s *subscription) Unsubscribe() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cancelled {
+		return nil
+	}
+	s.cancelled = true
+	return s.client.Unsubscribe(s.param)
+}
+
+func (s *subscription) Service() model.Service {
+	service := s.client.hostReactor.GetServiceInfo(context.Background(), s.serviceName, s.clusters)
+	service.Hosts = s.selector.filter(service.Hosts)
+	return service
+}
+
+func (s *subscription) Err() error {
+	s.mutex.Lock()
+	cancelled := s.cancelled
+	s.mutex.Unlock()
+	if cancelled {
+		return errors.New("[client.Subscribe] subscription is cancelled")
+	}
+	return s.client.hostReactor.updateError(s.serviceName, s.clusters)
+}
+
+func newSubscription(client *NamingClient, param *vo.SubscribeParam, selector *instanceSelector) *subscription {
+	return &subscription{
+		client:      client,
+		param:       param,
+		selector:    selector,
+		serviceName: utils.GetGroupName(param.ServiceName, param.GroupName),
+		clusters:    strings.Join(param.Clusters, ","),
+	}
+}
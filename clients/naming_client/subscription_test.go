@@ -0,0 +1,108 @@
+package naming_client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/clients/nacos_client"
+	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/mock"
+	"github.com/uugtv/nacos-sdk-go/model"
+	"github.com/uugtv/nacos-sdk-go/vo"
+)
+
+func createSubscriptionTestClient(ctrl *gomock.Controller) NamingClient {
+	mockIHttpAgent := mock.NewMockIHttpAgent(ctrl)
+	mockIHttpAgent.EXPECT().RequestWithContext(gomock.Any(), gomock.Eq(http.MethodGet),
+		gomock.Eq("http://console.nacos.io:80/nacos/v1/ns/instance/list"),
+		gomock.AssignableToTypeOf(http.Header{}),
+		gomock.Eq(clientConfigTest.TimeoutMs),
+		gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, method string, path string, header http.Header, timeoutMs uint64,
+			params map[string]string) (*http.Response, error) {
+			if params["serviceName"] == "DEFAULT_GROUP@@BROKEN" {
+				return nil, errors.New("connection refused")
+			}
+			return http_agent.FakeHttpResponse(200, `{"name":"`+params["serviceName"]+`","clusters":"a","cacheMillis":10000,`+
+				`"hosts":[{"ip":"10.0.0.1","port":80,"weight":1,"metadata":{"version":"1"}},`+
+				`{"ip":"10.0.0.2","port":80,"weight":1,"metadata":{"version":"2"}}]}`), nil
+		})
+
+	nc := nacos_client.NacosClient{}
+	nc.SetServerConfig([]constant.ServerConfig{serverConfigTest})
+	nc.SetClientConfig(clientConfigTest)
+	nc.SetHttpAgent(mockIHttpAgent)
+	client, _ := NewNamingClient(&nc)
+	return client
+}
+
+func TestNamingClient_Subscription(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := createSubscriptionTestClient(ctrl)
+	defer client.Close()
+
+	callback := func(services []model.SubscribeService, err error) {}
+	first, err := client.Subscribe(&vo.SubscribeParam{ServiceName: "DEMO", Clusters: []string{"a"}, SubscribeCallback: callback})
+	assert.Nil(t, err)
+	second, err := client.Subscribe(&vo.SubscribeParam{ServiceName: "DEMO", Clusters: []string{"a"},
+		Selector: &model.ExpressionSelector{Expression: "version=2"}, SubscribeCallback: callback})
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(first.Service().Hosts))
+	assert.Equal(t, 1, len(second.Service().Hosts))
+	assert.Equal(t, "10.0.0.2", second.Service().Hosts[0].Ip)
+	assert.Nil(t, first.Err())
+
+	// 还有其它订阅时继续刷新
+	assert.Nil(t, first.Unsubscribe())
+	assert.Nil(t, first.Unsubscribe())
+	assert.NotNil(t, first.Err())
+	assert.True(t, client.hostReactor.serviceInfoMap.Has("DEFAULT_GROUP@@DEMO@@a"))
+
+	// 最后一个订阅取消后停止刷新，并忽略推送
+	assert.Nil(t, second.Unsubscribe())
+	assert.False(t, client.hostReactor.serviceInfoMap.Has("DEFAULT_GROUP@@DEMO@@a"))
+	client.hostReactor.ProcessServiceJson(`{"name":"DEFAULT_GROUP@@DEMO","clusters":"a","cacheMillis":10000,` +
+		`"hosts":[{"ip":"10.0.0.3","port":80,"weight":1}]}`)
+	assert.False(t, client.hostReactor.serviceInfoMap.Has("DEFAULT_GROUP@@DEMO@@a"))
+
+	// 再次获取时恢复
+	service, err := client.GetService(vo.GetServiceParam{ServiceName: "DEMO", Clusters: []string{"a"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(service.Hosts))
+	assert.True(t, client.hostReactor.serviceInfoMap.Has("DEFAULT_GROUP@@DEMO@@a"))
+}
+
+func TestNamingClient_UnsubscribeDefaultGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := createSubscriptionTestClient(ctrl)
+	defer client.Close()
+
+	param := &vo.SubscribeParam{ServiceName: "DEMO", Clusters: []string{"a"},
+		SubscribeCallback: func(services []model.SubscribeService, err error) {}}
+	_, err := client.Subscribe(param)
+	assert.Nil(t, err)
+	param.GroupName = ""
+	assert.Nil(t, client.Unsubscribe(param))
+	assert.False(t, client.subCallback.hasListeners("DEFAULT_GROUP@@DEMO", "a"))
+}
+
+func TestNamingClient_SubscriptionErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := createSubscriptionTestClient(ctrl)
+	defer client.Close()
+
+	subscription, err := client.Subscribe(&vo.SubscribeParam{ServiceName: "BROKEN", Clusters: []string{"a"},
+		SubscribeCallback: func(services []model.SubscribeService, err error) {}})
+	assert.Nil(t, err)
+	assert.NotNil(t, subscription.Err())
+	assert.Equal(t, 0, len(subscription.Service().Hosts))
+}
@@ -0,0 +1,181 @@
+package naming_client
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// schedulerClock 调度器使用的时钟，测试时替换为可以手动推进的时钟
+type schedulerClock interface {
+	Now() time.Time
+	// Timer 返回 d 之后触发的 channel，以及提前停止的函数
+	Timer(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Timer(d time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
+// timerTask 定时任务的调度状态，具体的任务内嵌它后交给 timerScheduler 调度
+type timerTask struct {
+	key     string
+	due     time.Time
+	running bool
+	index   int
+}
+
+func newTimerTask(key string) timerTask {
+	return timerTask{key: key, index: -1}
+}
+
+func (t *timerTask) timer() *timerTask {
+	return t
+}
+
+type scheduledTask interface {
+	timer() *timerTask
+}
+
+// timerQueue 按到期时间排序的最小堆
+type timerQueue []scheduledTask
+
+func (q timerQueue) Len() int           { return len(q) }
+func (q timerQueue) Less(i, j int) bool { return q[i].timer().due.Before(q[j].timer().due) }
+func (q timerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].timer().index = i
+	q[j].timer().index = j
+}
+
+func (q *timerQueue) Push(x interface{}) {
+	task := x.(scheduledTask)
+	task.timer().index = len(*q)
+	*q = append(*q, task)
+}
+
+func (q *timerQueue) Pop() interface{} {
+	old := *q
+	task := old[len(old)-1]
+	old[len(old)-1] = nil
+	task.timer().index = -1
+	*q = old[:len(old)-1]
+	return task
+}
+
+// timerScheduler 由一个调度线程按任务各自的到期时间调度，到期的任务交给固定数量的线程执行
+// 到期时间在 window 之内的任务和已到期的任务一起执行，减少调度线程被唤醒的次数
+// 任务执行期间不在堆中，由 execute 在完成后重新加入；堆和任务的状态只在持有 mutex 时读写
+type timerScheduler struct {
+	clock   schedulerClock
+	window  time.Duration
+	execute func(task scheduledTask)
+	mutex   sync.Mutex
+	queue   timerQueue
+	jobs    chan scheduledTask
+	wakeup  chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newTimerScheduler(threadNum int, clock schedulerClock, window time.Duration,
+	execute func(task scheduledTask)) *timerScheduler {
+	s := &timerScheduler{
+		clock:   clock,
+		window:  window,
+		execute: execute,
+		jobs:    make(chan scheduledTask),
+		wakeup:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	for i := 0; i < threadNum; i++ {
+		go s.work()
+	}
+	return s
+}
+
+// pushLocked 将任务按到期时间加入堆，任务已在堆中时调整位置，调用方需持有 mutex 并在释放后调用 wake
+func (s *timerScheduler) pushLocked(task scheduledTask) {
+	if index := task.timer().index; index >= 0 {
+		heap.Fix(&s.queue, index)
+	} else {
+		heap.Push(&s.queue, task)
+	}
+}
+
+// removeLocked 将任务移出堆，调用方需持有 mutex
+func (s *timerScheduler) removeLocked(task scheduledTask) {
+	if index := task.timer().index; index >= 0 {
+		heap.Remove(&s.queue, index)
+	}
+}
+
+func (s *timerScheduler) wake() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (s *timerScheduler) run() {
+	for {
+		s.mutex.Lock()
+		now := s.clock.Now()
+		var due []scheduledTask
+		for len(s.queue) > 0 && !s.queue[0].timer().due.After(now.Add(s.window)) {
+			task := heap.Pop(&s.queue).(scheduledTask)
+			task.timer().running = true
+			due = append(due, task)
+		}
+		var timer <-chan time.Time
+		stop := func() {}
+		if len(due) == 0 && len(s.queue) > 0 {
+			timer, stop = s.clock.Timer(s.queue[0].timer().due.Add(-s.window).Sub(now))
+		}
+		s.mutex.Unlock()
+
+		for _, task := range due {
+			select {
+			case s.jobs <- task:
+			case <-s.done:
+				return
+			}
+		}
+		if len(due) > 0 {
+			continue
+		}
+		select {
+		case <-timer:
+		case <-s.wakeup:
+		case <-s.done:
+			stop()
+			return
+		}
+		stop()
+	}
+}
+
+func (s *timerScheduler) work() {
+	for {
+		select {
+		case task := <-s.jobs:
+			s.execute(task)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *timerScheduler) stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
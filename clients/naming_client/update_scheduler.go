@@ -0,0 +1,151 @@
+package naming_client
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/uugtv/nacos-sdk-go/common/logger"
+	"github.com/uugtv/nacos-sdk-go/utils"
+)
+
+const (
+	Default_Update_Min_Interval = time.Second
+	Default_Update_Backoff_Base = time.Second
+	Default_Update_Backoff_Max  = time.Minute
+	// 下次刷新时间额外增加 [0, interval*update_jitter_ratio) 的随机延迟，避免大量服务同时刷新
+	update_jitter_ratio = 0.1
+)
+
+type updateTask struct {
+	timerTask
+	serviceName string
+	clusters    string
+	failures    uint
+}
+
+// updateScheduler 按每个服务各自的下次刷新时间调度刷新，到期的服务交给固定数量的线程执行
+// 刷新成功后按返回的间隔（加随机抖动）再次调度，失败时按指数退避重试
+type updateScheduler struct {
+	*timerScheduler
+	refresh func(serviceName string, clusters string) (time.Duration, error)
+	jitter  func(interval time.Duration) time.Duration
+	tasks   map[string]*updateTask
+}
+
+func newUpdateScheduler(threadNum int, clock schedulerClock,
+	refresh func(serviceName string, clusters string) (time.Duration, error)) *updateScheduler {
+	if threadNum <= 0 {
+		threadNum = Default_Update_Thread_Num
+	}
+	s := &updateScheduler{
+		refresh: refresh,
+		jitter:  randomJitter,
+		tasks:   map[string]*updateTask{},
+	}
+	s.timerScheduler = newTimerScheduler(threadNum, clock, 0, s.execute)
+	return s
+}
+
+func randomJitter(interval time.Duration) time.Duration {
+	max := int64(float64(interval) * update_jitter_ratio)
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(max))
+}
+
+// backoff 第 n 次连续失败后的重试间隔：base*2^(n-1)，不超过 Default_Update_Backoff_Max
+func backoff(failures uint) time.Duration {
+	delay := Default_Update_Backoff_Base
+	for i := uint(1); i < failures && delay < Default_Update_Backoff_Max; i++ {
+		delay *= 2
+	}
+	if delay > Default_Update_Backoff_Max {
+		delay = Default_Update_Backoff_Max
+	}
+	return delay
+}
+
+// schedule 在 interval 之后刷新服务，服务已在调度中时推迟到 interval 之后，正在刷新时不做处理
+func (s *updateScheduler) schedule(serviceName string, clusters string, interval time.Duration) {
+	s.reschedule(serviceName, clusters, interval, nil)
+}
+
+// retry 服务刷新失败，按退避间隔重试
+func (s *updateScheduler) retry(serviceName string, clusters string, err error) {
+	s.reschedule(serviceName, clusters, 0, err)
+}
+
+func (s *updateScheduler) reschedule(serviceName string, clusters string, interval time.Duration, err error) {
+	key := utils.GetServiceCacheKey(serviceName, clusters)
+	s.mutex.Lock()
+	task, ok := s.tasks[key]
+	if !ok {
+		task = &updateTask{timerTask: newTimerTask(key), serviceName: serviceName, clusters: clusters}
+		s.tasks[key] = task
+	} else if task.running {
+		s.mutex.Unlock()
+		return
+	}
+	s.setNextLocked(task, interval, err)
+	s.mutex.Unlock()
+	s.wake()
+}
+
+func (s *updateScheduler) setNextLocked(task *updateTask, interval time.Duration, err error) {
+	var delay time.Duration
+	if err != nil {
+		task.failures++
+		delay = backoff(task.failures)
+	} else {
+		task.failures = 0
+		if interval < Default_Update_Min_Interval {
+			interval = Default_Update_Min_Interval
+		}
+		delay = interval
+	}
+	task.due = s.clock.Now().Add(delay + s.jitter(delay))
+	s.pushLocked(task)
+}
+
+// remove 停止刷新服务，正在进行的刷新完成后不再调度
+func (s *updateScheduler) remove(serviceName string, clusters string) {
+	key := utils.GetServiceCacheKey(serviceName, clusters)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	task, ok := s.tasks[key]
+	if !ok {
+		return
+	}
+	delete(s.tasks, key)
+	s.removeLocked(task)
+}
+
+func (s *updateScheduler) size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.tasks)
+}
+
+func (s *updateScheduler) execute(task scheduledTask) {
+	updateTask := task.(*updateTask)
+	interval, err := s.refresh(updateTask.serviceName, updateTask.clusters)
+	s.finish(updateTask, interval, err)
+}
+
+func (s *updateScheduler) finish(task *updateTask, interval time.Duration, err error) {
+	s.mutex.Lock()
+	task.running = false
+	if s.tasks[task.key] != task {
+		// 刷新期间已被移除
+		s.mutex.Unlock()
+		return
+	}
+	s.setNextLocked(task, interval, err)
+	if err != nil {
+		logger.Warn("[HostReactor] update service failed, retry later", logger.F("key", task.key),
+			logger.F("failures", task.failures), logger.F("next", task.due), logger.Err(err))
+	}
+	s.mutex.Unlock()
+	s.wake()
+}
@@ -0,0 +1,274 @@
+package naming_client
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock 只有调用 Advance 时才会前进的时钟
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Timer(d time.Duration) (<-chan time.Time, func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timer := &fakeTimer{deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+		return timer.c, func() {}
+	}
+	c.timers = append(c.timers, timer)
+	return timer.c, func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for i, t := range c.timers {
+			if t == timer {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	var remain []*fakeTimer
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.now) {
+			remain = append(remain, timer)
+		} else {
+			timer.c <- c.now
+		}
+	}
+	c.timers = remain
+}
+
+func newTestUpdateScheduler(threadNum int, clock schedulerClock,
+	refresh func(serviceName string, clusters string) (time.Duration, error)) *updateScheduler {
+	s := newUpdateScheduler(threadNum, clock, refresh)
+	s.mutex.Lock()
+	s.jitter = func(interval time.Duration) time.Duration { return 0 }
+	s.mutex.Unlock()
+	return s
+}
+
+// waitScheduled 等待没有正在刷新的服务，保证之后推进时钟时下次刷新时间已经确定
+func waitScheduled(t *testing.T, s *updateScheduler) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mutex.Lock()
+		running := false
+		for _, task := range s.tasks {
+			running = running || task.running
+		}
+		s.mutex.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("update task is still running")
+}
+
+func expectRefresh(t *testing.T, calls chan string, expected string) {
+	select {
+	case name := <-calls:
+		assert.Equal(t, expected, name)
+	case <-time.After(time.Second):
+		t.Fatalf("expect refresh of %s", expected)
+	}
+}
+
+func expectNoRefresh(t *testing.T, calls chan string) {
+	select {
+	case name := <-calls:
+		t.Fatalf("unexpected refresh of %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUpdateScheduler_Order(t *testing.T) {
+	clock := newFakeClock()
+	calls := make(chan string, 10)
+	s := newTestUpdateScheduler(1, clock, func(serviceName string, clusters string) (time.Duration, error) {
+		calls <- serviceName
+		return 10 * time.Second, nil
+	})
+	defer s.stop()
+
+	s.schedule("A", "", 5*time.Second)
+	s.schedule("B", "", 2*time.Second)
+	s.schedule("C", "", 8*time.Second)
+	expectNoRefresh(t, calls)
+
+	clock.Advance(2 * time.Second)
+	expectRefresh(t, calls, "B")
+	expectNoRefresh(t, calls)
+	waitScheduled(t, s)
+	clock.Advance(3 * time.Second)
+	expectRefresh(t, calls, "A")
+	waitScheduled(t, s)
+	clock.Advance(3 * time.Second)
+	expectRefresh(t, calls, "C")
+	waitScheduled(t, s)
+	// B 在第 2 秒刷新后间隔 10 秒
+	clock.Advance(3 * time.Second)
+	expectNoRefresh(t, calls)
+	clock.Advance(time.Second)
+	expectRefresh(t, calls, "B")
+}
+
+func TestUpdateScheduler_Backoff(t *testing.T) {
+	clock := newFakeClock()
+	calls := make(chan string, 10)
+	var failed int32 = 1
+	s := newTestUpdateScheduler(1, clock, func(serviceName string, clusters string) (time.Duration, error) {
+		calls <- serviceName
+		if atomic.LoadInt32(&failed) == 1 {
+			return 0, errors.New("connection refused")
+		}
+		return 10 * time.Second, nil
+	})
+	defer s.stop()
+
+	s.schedule("A", "", time.Second)
+	clock.Advance(time.Second)
+	expectRefresh(t, calls, "A")
+	// 连续失败后依次间隔 1、2、4 秒重试
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		waitScheduled(t, s)
+		clock.Advance(delay - time.Millisecond)
+		expectNoRefresh(t, calls)
+		clock.Advance(time.Millisecond)
+		expectRefresh(t, calls, "A")
+	}
+
+	// 恢复后按正常间隔刷新
+	atomic.StoreInt32(&failed, 0)
+	waitScheduled(t, s)
+	clock.Advance(8 * time.Second)
+	expectRefresh(t, calls, "A")
+	waitScheduled(t, s)
+	clock.Advance(8 * time.Second)
+	expectNoRefresh(t, calls)
+	clock.Advance(2 * time.Second)
+	expectRefresh(t, calls, "A")
+
+	assert.Equal(t, Default_Update_Backoff_Base, backoff(1))
+	assert.Equal(t, 8*Default_Update_Backoff_Base, backoff(4))
+	assert.Equal(t, Default_Update_Backoff_Max, backoff(100))
+}
+
+func TestUpdateScheduler_BoundedWorkers(t *testing.T) {
+	clock := newFakeClock()
+	release := make(chan struct{})
+	var running, maxRunning, total int32
+	s := newTestUpdateScheduler(2, clock, func(serviceName string, clusters string) (time.Duration, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&total, 1)
+		return 10 * time.Second, nil
+	})
+	defer s.stop()
+
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		s.schedule(name, "", time.Second)
+	}
+	clock.Advance(time.Second)
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&running) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&running))
+
+	close(release)
+	deadline = time.Now().Add(time.Second)
+	for atomic.LoadInt32(&total) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(5), atomic.LoadInt32(&total))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestUpdateScheduler_PostponeAndRemove(t *testing.T) {
+	clock := newFakeClock()
+	calls := make(chan string, 10)
+	s := newTestUpdateScheduler(1, clock, func(serviceName string, clusters string) (time.Duration, error) {
+		calls <- serviceName
+		return 10 * time.Second, nil
+	})
+	defer s.stop()
+
+	s.schedule("A", "", 5*time.Second)
+	// 收到推送后推迟下次刷新
+	clock.Advance(3 * time.Second)
+	s.schedule("A", "", 5*time.Second)
+	clock.Advance(3 * time.Second)
+	expectNoRefresh(t, calls)
+	clock.Advance(2 * time.Second)
+	expectRefresh(t, calls, "A")
+	waitScheduled(t, s)
+
+	s.remove("A", "")
+	assert.Equal(t, 0, s.size())
+	clock.Advance(time.Minute)
+	expectNoRefresh(t, calls)
+}
+
+func TestUpdateScheduler_Jitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		jitter := randomJitter(10 * time.Second)
+		assert.True(t, jitter >= 0 && jitter < time.Second)
+	}
+	assert.Equal(t, time.Duration(0), randomJitter(0))
+
+	clock := newFakeClock()
+	calls := make(chan string, 10)
+	s := newUpdateScheduler(1, clock, func(serviceName string, clusters string) (time.Duration, error) {
+		calls <- serviceName
+		return 10 * time.Second, nil
+	})
+	defer s.stop()
+	s.mutex.Lock()
+	s.jitter = func(interval time.Duration) time.Duration { return interval / 4 }
+	s.mutex.Unlock()
+
+	s.schedule("A", "", 2*time.Second)
+	clock.Advance(2 * time.Second)
+	expectNoRefresh(t, calls)
+	clock.Advance(500 * time.Millisecond)
+	expectRefresh(t, calls, "A")
+}
@@ -16,18 +16,34 @@ type ServerConfig struct {
 }
 
 type ClientConfig struct {
-	TimeoutMs            uint64
-	ListenInterval       uint64
-	BeatInterval         int64
-	NamespaceId          string
-	Endpoint             string
-	AccessKey            string
-	SecretKey            string
-	CacheDir             string
-	LogDir               string
-	UpdateThreadNum      int
-	NotLoadCacheAtStart  bool
-	UpdateCacheWhenEmpty bool
-	OpenKMS              bool
-	RegionId             string
+	TimeoutMs              uint64
+	ListenInterval         uint64
+	BeatInterval           int64
+	BeatThreadNum          int
+	BeatFailureThreshold   int
+	NamespaceId            string
+	Endpoint               string
+	AccessKey              string
+	SecretKey              string
+	CacheDir               string
+	LogDir                 string
+	LogLevel               string
+	UpdateThreadNum        int
+	NotLoadCacheAtStart    bool
+	UpdateCacheWhenEmpty   bool
+	ServiceIdleTimeMs      uint64
+	OpenKMS                bool
+	RegionId               string
+	KMSKeyId               string
+	CipherKeyFile          string
+	PerTaskConfigSize      int
+	LoadBalancer           string
+	Labels                 map[string]string
+	ClusterName            string
+	Zone                   string
+	ZoneMetadataKey        string
+	LocalityThreshold      float64
+	CallbackThreadNum      int
+	CallbackQueueSize      int
+	CallbackOverflowPolicy string
 }
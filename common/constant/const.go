@@ -53,10 +53,36 @@ const (
 	DEFAULT_CONTEXT_PATH        = "/nacos"
 	CLIENT_VERSION              = "Nacos-go-Client:v1.0.0"
 	REQUEST_DOMAIN_RETRY_TIME   = 3
+	PER_TASK_CONFIG_SIZE        = 3000
 	SERVICE_INFO_SPLITER        = "@@"
 	CONFIG_INFO_SPLITER         = "@@"
 	DEFAULT_NAMESPACE_ID        = "public"
 	DEFAULT_GROUP               = "DEFAULT_GROUP"
 	NAMING_INSTANCE_ID_SPLITTER = "#"
 	DefaultClientErrorCode      = "SDK.NacosError"
+	CONFIG_FORMAT_JSON          = "json"
+	CONFIG_FORMAT_YAML          = "yaml"
+	CONFIG_FORMAT_TOML          = "toml"
+	CONFIG_FORMAT_PROPERTIES    = "properties"
+	CIPHER_DATA_ID_PREFIX       = "cipher-"
+	SELECTOR_TYPE_NONE          = "none"
+	SELECTOR_TYPE_LABEL         = "label"
+	SELECTOR_TYPE_EXPRESSION    = "expression"
+	INSTANCE_HEALTH_ANY         = "any"
+	INSTANCE_HEALTH_HEALTHY     = "healthy"
+	INSTANCE_HEALTH_UNHEALTHY   = "unhealthy"
+	INSTANCE_TYPE_ANY           = "any"
+	INSTANCE_TYPE_EPHEMERAL     = "ephemeral"
+	INSTANCE_TYPE_PERSISTENT    = "persistent"
+	DEFAULT_ZONE_METADATA_KEY   = "zone"
+	LOCALITY_TIER_CLUSTER       = "cluster"
+	LOCALITY_TIER_ZONE          = "zone"
+	LOCALITY_TIER_ALL           = "all"
+	DISPATCH_POLICY_DROP_OLDEST = "drop_oldest"
+	DISPATCH_POLICY_DROP_NEWEST = "drop_newest"
+	DISPATCH_POLICY_COALESCE    = "coalesce"
+	INSTANCE_EVENT_REREGISTERED = "reregistered"
+	INSTANCE_EVENT_BEAT_FAILED  = "beat_failed"
+	INSTANCE_EVENT_RECOVERED    = "beat_recovered"
+	RESOURCE_NOT_FOUND_CODE     = 20404
 )
@@ -1,6 +1,7 @@
 package http_agent
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
@@ -15,7 +16,7 @@ import (
 * @create : 2019-01-08 14:08
 **/
 
-func delete(path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
+func delete(ctx context.Context, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
 	if !strings.HasSuffix(path, "?") {
 		path = path + "?"
 	}
@@ -32,6 +33,7 @@ func delete(path string, header http.Header, timeoutMs uint64, params map[string
 		err = errNew
 		return
 	}
+	request = request.WithContext(ctx)
 	request.Header = header
 	resp, errDo := client.Do(request)
 	if errDo != nil {
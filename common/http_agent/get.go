@@ -1,6 +1,7 @@
 package http_agent
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
@@ -15,7 +16,7 @@ import (
 * @create : 2019-01-07 15:13
 **/
 
-func get(path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
+func get(ctx context.Context, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
 	if !strings.HasSuffix(path, "?") {
 		path = path + "?"
 	}
@@ -34,6 +35,7 @@ func get(path string, header http.Header, timeoutMs uint64, params map[string]st
 		err = errNew
 		return
 	}
+	request = request.WithContext(ctx)
 	request.Header = header
 	resp, errDo := client.Do(request)
 
@@ -1,10 +1,13 @@
 package http_agent
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/go-errors/errors"
+
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 )
 
 /**
@@ -20,7 +23,7 @@ type HttpAgent struct {
 
 func (agent *HttpAgent) Get(path string, header http.Header, timeoutMs uint64,
 	params map[string]string) (response *http.Response, err error) {
-	return get(path, header, timeoutMs, params)
+	return get(context.Background(), path, header, timeoutMs, params)
 }
 
 func (agent *HttpAgent) RequestOnlyResult(method string, path string, header http.Header, timeoutMs uint64, params map[string]string) string {
@@ -40,20 +43,20 @@ func (agent *HttpAgent) RequestOnlyResult(method string, path string, header htt
 		response, err = agent.Delete(path, header, timeoutMs, params)
 		break
 	default:
-		// log.Printf("[ERROR]:request method[%s], path[%s],header:[%s],params:[%s], not avaliable method ", method, path, utils.ToJsonString(header), utils.ToJsonString(params))
+		logger.Error("[HttpAgent] not avaliable method", logger.F("method", method), logger.F("path", path))
 	}
 	if err != nil {
-		// log.Printf("[ERROR]:request method[%s],request path[%s],header:[%s],params:[%s],err:%s", method, path, utils.ToJsonString(header), utils.ToJsonString(params), err.Error())
+		logger.Error("[HttpAgent] request error", logger.F("method", method), logger.F("path", path), logger.Err(err))
 		return ""
 	}
 	if response.StatusCode != 200 {
-		// log.Printf("[ERROR]:request method[%s],request path[%s],header:[%s],params:[%s],status code error:%d", method, path, utils.ToJsonString(header), utils.ToJsonString(params), response.StatusCode)
+		logger.Error("[HttpAgent] status code error", logger.F("method", method), logger.F("path", path), logger.F("statusCode", response.StatusCode))
 		return ""
 	}
 	bytes, errRead := ioutil.ReadAll(response.Body)
 	defer response.Body.Close()
 	if errRead != nil {
-		// log.Printf("[ERROR]:request method[%s],request path[%s],header:[%s],params:[%s],read error:%s", method, path, utils.ToJsonString(header), utils.ToJsonString(params), errRead.Error())
+		logger.Error("[HttpAgent] read response error", logger.F("method", method), logger.F("path", path), logger.Err(errRead))
 		return ""
 	}
 	return string(bytes)
@@ -61,34 +64,38 @@ func (agent *HttpAgent) RequestOnlyResult(method string, path string, header htt
 }
 
 func (agent *HttpAgent) Request(method string, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
+	return agent.RequestWithContext(context.Background(), method, path, header, timeoutMs, params)
+}
+
+func (agent *HttpAgent) RequestWithContext(ctx context.Context, method string, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
 	switch method {
 	case http.MethodGet:
-		response, err = agent.Get(path, header, timeoutMs, params)
+		response, err = get(ctx, path, header, timeoutMs, params)
 		return
 	case http.MethodPost:
-		response, err = agent.Post(path, header, timeoutMs, params)
+		response, err = post(ctx, path, header, timeoutMs, params)
 		return
 	case http.MethodPut:
-		response, err = agent.Put(path, header, timeoutMs, params)
+		response, err = put(ctx, path, header, timeoutMs, params)
 		return
 	case http.MethodDelete:
-		response, err = agent.Delete(path, header, timeoutMs, params)
+		response, err = delete(ctx, path, header, timeoutMs, params)
 		return
 	default:
 		err = errors.New("not avaliable method")
-		// log.Printf("[ERROR]:request method[%s], path[%s],header:[%s],params:[%s], not avaliable method ", method, path, utils.ToJsonString(header), utils.ToJsonString(params))
+		logger.Error("[HttpAgent] not avaliable method", logger.F("method", method), logger.F("path", path))
 	}
 	return
 }
 func (agent *HttpAgent) Post(path string, header http.Header, timeoutMs uint64,
 	params map[string]string) (response *http.Response, err error) {
-	return post(path, header, timeoutMs, params)
+	return post(context.Background(), path, header, timeoutMs, params)
 }
 func (agent *HttpAgent) Delete(path string, header http.Header, timeoutMs uint64,
 	params map[string]string) (response *http.Response, err error) {
-	return delete(path, header, timeoutMs, params)
+	return delete(context.Background(), path, header, timeoutMs, params)
 }
 func (agent *HttpAgent) Put(path string, header http.Header, timeoutMs uint64,
 	params map[string]string) (response *http.Response, err error) {
-	return put(path, header, timeoutMs, params)
+	return put(context.Background(), path, header, timeoutMs, params)
 }
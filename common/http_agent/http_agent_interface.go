@@ -1,6 +1,9 @@
 package http_agent
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 /**
 *
@@ -20,4 +23,6 @@ type IHttpAgent interface {
 	Put(path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error)
 	RequestOnlyResult(method string, path string, header http.Header, timeoutMs uint64, params map[string]string) string
 	Request(method string, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error)
+	// ctx 取消或超时后请求立即返回
+	RequestWithContext(ctx context.Context, method string, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error)
 }
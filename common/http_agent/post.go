@@ -1,6 +1,7 @@
 package http_agent
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
@@ -15,7 +16,7 @@ import (
 * @create : 2019-01-07 15:13
 **/
 
-func post(path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
+func post(ctx context.Context, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
 	client := http.Client{}
 	client.Timeout = time.Millisecond * time.Duration(timeoutMs)
 	var body string
@@ -32,6 +33,7 @@ func post(path string, header http.Header, timeoutMs uint64, params map[string]s
 		err = errNew
 		return
 	}
+	request = request.WithContext(ctx)
 	request.Header = header
 	resp, errDo := client.Do(request)
 	if errDo != nil {
@@ -1,9 +1,12 @@
 package http_agent
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 )
 
 /**
@@ -15,7 +18,7 @@ import (
 * @create : 2019-01-09 11:24
 **/
 
-func put(path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
+func put(ctx context.Context, path string, header http.Header, timeoutMs uint64, params map[string]string) (response *http.Response, err error) {
 	client := http.Client{}
 	client.Timeout = time.Millisecond * time.Duration(timeoutMs)
 	var body string
@@ -32,10 +35,11 @@ func put(path string, header http.Header, timeoutMs uint64, params map[string]st
 		err = errNew
 		return
 	}
+	request = request.WithContext(ctx)
 	request.Header = header
 	resp, errDo := client.Do(request)
 	if errDo != nil {
-		// log.Println(errDo)
+		logger.Error("[HttpAgent] put request error", logger.F("path", path), logger.Err(errDo))
 		err = errDo
 	} else {
 		response = resp
@@ -0,0 +1,247 @@
+package loadbalancer
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+// RoundRobinBalancer 平滑加权轮询（与 nginx 相同），支持小数权重，不需要按权重展开实例列表
+type RoundRobinBalancer struct {
+	mutex   sync.Mutex
+	current map[string]map[string]float64
+}
+
+func NewRoundRobinBalancer() *RoundRobinBalancer {
+	return &RoundRobinBalancer{current: map[string]map[string]float64{}}
+}
+
+func (b *RoundRobinBalancer) Select(serviceKey string, instances []model.Instance, hashKey string) (*model.Instance, error) {
+	if len(instances) == 0 {
+		return nil, errEmptyInstances
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	previous := b.current[serviceKey]
+	current := make(map[string]float64, len(instances))
+	total := 0.0
+	best := -1
+	for i, instance := range instances {
+		key := instanceKey(instance)
+		weight := weightOf(instance)
+		current[key] = previous[key] + weight
+		total += weight
+		if best < 0 || current[key] > current[instanceKey(instances[best])] {
+			best = i
+		}
+	}
+	if total <= 0 {
+		// 权重都不大于 0 时退化为普通轮询
+		for _, instance := range instances {
+			current[instanceKey(instance)] += 1
+			total += 1
+		}
+		best = 0
+		for i, instance := range instances {
+			if current[instanceKey(instance)] > current[instanceKey(instances[best])] {
+				best = i
+			}
+		}
+	}
+	current[instanceKey(instances[best])] -= total
+	// 只保留当前实例的状态，下线的实例随之清理
+	b.current[serviceKey] = current
+	instance := instances[best]
+	return &instance, nil
+}
+
+// RandomBalancer 加权随机
+type RandomBalancer struct {
+}
+
+func NewRandomBalancer() *RandomBalancer {
+	return &RandomBalancer{}
+}
+
+func (b *RandomBalancer) Select(serviceKey string, instances []model.Instance, hashKey string) (*model.Instance, error) {
+	if len(instances) == 0 {
+		return nil, errEmptyInstances
+	}
+	instance := instances[weightedRandom(instances)]
+	return &instance, nil
+}
+
+// LeastActiveBalancer 选择活跃请求数最少的实例，数量相同时加权随机
+// 选中即计为一次活跃请求，调用方需要在请求结束后调用 Done
+type LeastActiveBalancer struct {
+	*activeCounter
+}
+
+func NewLeastActiveBalancer() *LeastActiveBalancer {
+	return &LeastActiveBalancer{activeCounter: newActiveCounter()}
+}
+
+func (b *LeastActiveBalancer) Select(serviceKey string, instances []model.Instance, hashKey string) (*model.Instance, error) {
+	if len(instances) == 0 {
+		return nil, errEmptyInstances
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var least []model.Instance
+	leastActive := int64(math.MaxInt64)
+	for _, instance := range instances {
+		active := b.get(serviceKey, instance)
+		if active < leastActive {
+			leastActive = active
+			least = least[:0]
+		}
+		if active == leastActive {
+			least = append(least, instance)
+		}
+	}
+	instance := least[weightedRandom(least)]
+	b.acquire(serviceKey, instance)
+	return &instance, nil
+}
+
+// P2CBalancer 按权重随机选出两个实例，取活跃请求数与权重之比较小的一个
+// 选中即计为一次活跃请求，调用方需要在请求结束后调用 Done
+type P2CBalancer struct {
+	*activeCounter
+}
+
+func NewP2CBalancer() *P2CBalancer {
+	return &P2CBalancer{activeCounter: newActiveCounter()}
+}
+
+func (b *P2CBalancer) Select(serviceKey string, instances []model.Instance, hashKey string) (*model.Instance, error) {
+	if len(instances) == 0 {
+		return nil, errEmptyInstances
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var instance model.Instance
+	if len(instances) == 1 {
+		instance = instances[0]
+	} else {
+		first := weightedRandom(instances)
+		rest := make([]model.Instance, 0, len(instances)-1)
+		rest = append(rest, instances[:first]...)
+		rest = append(rest, instances[first+1:]...)
+		instance = instances[first]
+		other := rest[weightedRandom(rest)]
+		if b.load(serviceKey, other) < b.load(serviceKey, instance) {
+			instance = other
+		}
+	}
+	b.acquire(serviceKey, instance)
+	return &instance, nil
+}
+
+func (b *P2CBalancer) load(serviceKey string, instance model.Instance) float64 {
+	weight := weightOf(instance)
+	if weight <= 0 {
+		weight = 1
+	}
+	return float64(b.get(serviceKey, instance)+1) / weight
+}
+
+const (
+	// 权重为 1 的实例的虚拟节点数，其它实例按自身权重缩放，不受其它实例权重的影响
+	virtual_node_count = 160
+	// 单个实例的虚拟节点数上限，避免权重很大时哈希环过大
+	virtual_node_max = 160 * 100
+)
+
+// ConsistentHashBalancer 按 hashKey 一致性哈希，虚拟节点数与权重成正比
+// 实例增减或调整权重时只有少部分 hashKey 会映射到其他实例，hashKey 为空时加权随机
+type ConsistentHashBalancer struct {
+	mutex sync.Mutex
+	rings map[string]*hashRing
+}
+
+// hashRing 只保存虚拟节点对应的实例地址，选择时映射回本次传入的实例
+type hashRing struct {
+	signature string
+	hashes    []uint32
+	keys      map[uint32]string
+}
+
+func NewConsistentHashBalancer() *ConsistentHashBalancer {
+	return &ConsistentHashBalancer{rings: map[string]*hashRing{}}
+}
+
+func (b *ConsistentHashBalancer) Select(serviceKey string, instances []model.Instance, hashKey string) (*model.Instance, error) {
+	if len(instances) == 0 {
+		return nil, errEmptyInstances
+	}
+	if hashKey == "" {
+		instance := instances[weightedRandom(instances)]
+		return &instance, nil
+	}
+	ring := b.ring(serviceKey, instances)
+	hash := hashOf(hashKey)
+	index := sort.Search(len(ring.hashes), func(i int) bool {
+		return ring.hashes[i] >= hash
+	})
+	if index == len(ring.hashes) {
+		index = 0
+	}
+	key := ring.keys[ring.hashes[index]]
+	for _, instance := range instances {
+		if instanceKey(instance) == key {
+			return &instance, nil
+		}
+	}
+	// 签名相同的哈希环一定包含全部实例，不会走到这里
+	instance := instances[weightedRandom(instances)]
+	return &instance, nil
+}
+
+func (b *ConsistentHashBalancer) ring(serviceKey string, instances []model.Instance) *hashRing {
+	keys := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		keys = append(keys, instanceKey(instance)+"#"+strconv.FormatFloat(instance.Weight, 'f', -1, 64))
+	}
+	sort.Strings(keys)
+	signature := strings.Join(keys, ",")
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if ring, ok := b.rings[serviceKey]; ok && ring.signature == signature {
+		return ring
+	}
+	ring := &hashRing{signature: signature, keys: map[uint32]string{}}
+	for _, instance := range instances {
+		key := instanceKey(instance)
+		for i := 0; i < replicasOf(instance); i++ {
+			hash := hashOf(key + "-" + strconv.Itoa(i))
+			if _, ok := ring.keys[hash]; ok {
+				continue
+			}
+			ring.keys[hash] = key
+			ring.hashes = append(ring.hashes, hash)
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool {
+		return ring.hashes[i] < ring.hashes[j]
+	})
+	b.rings[serviceKey] = ring
+	return ring
+}
+
+// replicasOf 虚拟节点数只取决于实例自身的权重，至少为 1
+func replicasOf(instance model.Instance) int {
+	return int(math.Min(virtual_node_max, math.Max(1, math.Round(virtual_node_count*weightOf(instance)))))
+}
+
+func hashOf(key string) uint32 {
+	sum := md5.Sum([]byte(key))
+	return binary.LittleEndian.Uint32(sum[:4])
+}
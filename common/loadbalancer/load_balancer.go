@@ -0,0 +1,119 @@
+package loadbalancer
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+const (
+	ROUND_ROBIN     = "round_robin"
+	RANDOM          = "random"
+	LEAST_ACTIVE    = "least_active"
+	CONSISTENT_HASH = "consistent_hash"
+	P2C             = "p2c"
+)
+
+// LoadBalancer 从可用实例中选出一个实例
+// serviceKey 区分不同服务（及集群）各自的选择状态，hashKey 由调用方提供，仅一致性哈希使用
+type LoadBalancer interface {
+	Select(serviceKey string, instances []model.Instance, hashKey string) (*model.Instance, error)
+}
+
+// ActiveTracker 由按活跃请求数选择的负载均衡实现，调用方在请求结束后以选择时的 serviceKey 调用 Done
+type ActiveTracker interface {
+	Done(serviceKey string, instance model.Instance)
+}
+
+// New 根据名称创建内置的负载均衡实现，名称为空时使用平滑加权轮询
+func New(name string) (LoadBalancer, error) {
+	switch name {
+	case "", ROUND_ROBIN:
+		return NewRoundRobinBalancer(), nil
+	case RANDOM:
+		return NewRandomBalancer(), nil
+	case LEAST_ACTIVE:
+		return NewLeastActiveBalancer(), nil
+	case CONSISTENT_HASH:
+		return NewConsistentHashBalancer(), nil
+	case P2C:
+		return NewP2CBalancer(), nil
+	}
+	return nil, errors.New("[loadbalancer.New] unknown load balancer:" + name)
+}
+
+var errEmptyInstances = errors.New("healthy instance list is empty!")
+
+func instanceKey(instance model.Instance) string {
+	return instance.Ip + ":" + strconv.FormatUint(instance.Port, 10)
+}
+
+func weightOf(instance model.Instance) float64 {
+	if instance.Weight <= 0 {
+		return 0
+	}
+	return instance.Weight
+}
+
+// weightedRandom 按权重随机选择下标，权重都不大于 0 时等概率选择
+func weightedRandom(instances []model.Instance) int {
+	total := 0.0
+	for _, instance := range instances {
+		total += weightOf(instance)
+	}
+	if total <= 0 {
+		return rand.Intn(len(instances))
+	}
+	offset := rand.Float64() * total
+	for i, instance := range instances {
+		offset -= weightOf(instance)
+		if offset < 0 {
+			return i
+		}
+	}
+	return len(instances) - 1
+}
+
+// activeCounter 按服务记录每个实例正在处理中的请求数，不同服务下相同地址的实例分别计数
+type activeCounter struct {
+	mutex  sync.Mutex
+	active map[string]map[string]int64
+}
+
+func newActiveCounter() *activeCounter {
+	return &activeCounter{active: map[string]map[string]int64{}}
+}
+
+func (c *activeCounter) get(serviceKey string, instance model.Instance) int64 {
+	return c.active[serviceKey][instanceKey(instance)]
+}
+
+func (c *activeCounter) acquire(serviceKey string, instance model.Instance) {
+	active, ok := c.active[serviceKey]
+	if !ok {
+		active = map[string]int64{}
+		c.active[serviceKey] = active
+	}
+	active[instanceKey(instance)]++
+}
+
+func (c *activeCounter) Done(serviceKey string, instance model.Instance) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	active, ok := c.active[serviceKey]
+	if !ok {
+		return
+	}
+	key := instanceKey(instance)
+	if active[key] > 1 {
+		active[key]--
+		return
+	}
+	delete(active, key)
+	if len(active) == 0 {
+		delete(c.active, serviceKey)
+	}
+}
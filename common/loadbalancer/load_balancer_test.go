@@ -0,0 +1,219 @@
+package loadbalancer
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
+
+const serviceKeyTest = "DEFAULT_GROUP@@DEMO@@a"
+
+func instancesTest(weights ...float64) []model.Instance {
+	var instances []model.Instance
+	for i, weight := range weights {
+		instances = append(instances, model.Instance{
+			Ip:      "10.0.0." + strconv.Itoa(i+1),
+			Port:    80,
+			Weight:  weight,
+			Enable:  true,
+			Healthy: true,
+		})
+	}
+	return instances
+}
+
+func countSelect(t *testing.T, balancer LoadBalancer, instances []model.Instance, times int) map[string]int {
+	counts := map[string]int{}
+	for i := 0; i < times; i++ {
+		instance, err := balancer.Select(serviceKeyTest, instances, "")
+		assert.Nil(t, err)
+		counts[instance.Ip]++
+	}
+	return counts
+}
+
+func assertRatio(t *testing.T, counts map[string]int, instances []model.Instance, times int, delta float64) {
+	total := 0.0
+	for _, instance := range instances {
+		total += instance.Weight
+	}
+	for _, instance := range instances {
+		expected := instance.Weight / total
+		actual := float64(counts[instance.Ip]) / float64(times)
+		assert.True(t, math.Abs(expected-actual) <= delta,
+			"%s expected %.3f actual %.3f", instance.Ip, expected, actual)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"", ROUND_ROBIN, RANDOM, LEAST_ACTIVE, CONSISTENT_HASH, P2C} {
+		balancer, err := New(name)
+		assert.Nil(t, err, name)
+		assert.NotNil(t, balancer, name)
+	}
+	_, err := New("unknown")
+	assert.NotNil(t, err)
+}
+
+func TestEmptyInstances(t *testing.T) {
+	for _, balancer := range []LoadBalancer{NewRoundRobinBalancer(), NewRandomBalancer(), NewLeastActiveBalancer(),
+		NewConsistentHashBalancer(), NewP2CBalancer()} {
+		instance, err := balancer.Select(serviceKeyTest, nil, "key")
+		assert.NotNil(t, err)
+		assert.Nil(t, instance)
+	}
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+	instances := instancesTest(5, 1, 1)
+	balancer := NewRoundRobinBalancer()
+	var sequence []string
+	for i := 0; i < 7; i++ {
+		instance, _ := balancer.Select(serviceKeyTest, instances, "")
+		sequence = append(sequence, instance.Ip)
+	}
+	// 平滑加权轮询：高权重实例不会连续被选中
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.1", "10.0.0.2", "10.0.0.1", "10.0.0.3", "10.0.0.1", "10.0.0.1"}, sequence)
+
+	// 小数权重
+	instances = instancesTest(0.5, 1.5)
+	counts := countSelect(t, NewRoundRobinBalancer(), instances, 1000)
+	assert.Equal(t, map[string]int{"10.0.0.1": 250, "10.0.0.2": 750}, counts)
+
+	// 权重都为 0 时退化为普通轮询
+	instances = instancesTest(0, 0)
+	counts = countSelect(t, NewRoundRobinBalancer(), instances, 10)
+	assert.Equal(t, map[string]int{"10.0.0.1": 5, "10.0.0.2": 5}, counts)
+}
+
+func TestRandomBalancer(t *testing.T) {
+	instances := instancesTest(1, 2, 7)
+	counts := countSelect(t, NewRandomBalancer(), instances, 20000)
+	assertRatio(t, counts, instances, 20000, 0.02)
+}
+
+func TestLeastActiveBalancer(t *testing.T) {
+	instances := instancesTest(1, 1, 1)
+	balancer := NewLeastActiveBalancer()
+	selected := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		instance, _ := balancer.Select(serviceKeyTest, instances, "")
+		selected[instance.Ip] = true
+	}
+	// 没有请求结束时依次选中每个实例
+	assert.Equal(t, 3, len(selected))
+
+	// 10.0.0.2 的请求结束后活跃数最少
+	balancer.Done(serviceKeyTest, instances[1])
+	instance, _ := balancer.Select(serviceKeyTest, instances, "")
+	assert.Equal(t, "10.0.0.2", instance.Ip)
+
+	// 其它服务下相同地址的实例单独计数
+	balancer.Done("DEFAULT_GROUP@@OTHER@@a", instances[0])
+	assert.Equal(t, int64(1), balancer.get(serviceKeyTest, instances[0]))
+	for _, instance := range instances {
+		balancer.Done(serviceKeyTest, instance)
+	}
+	assert.Equal(t, 0, len(balancer.active))
+
+	// 活跃数相同时按权重随机
+	instances = instancesTest(1, 3)
+	balancer = NewLeastActiveBalancer()
+	counts := map[string]int{}
+	for i := 0; i < 20000; i++ {
+		instance, _ := balancer.Select(serviceKeyTest, instances, "")
+		counts[instance.Ip]++
+		balancer.Done(serviceKeyTest, *instance)
+	}
+	assertRatio(t, counts, instances, 20000, 0.02)
+}
+
+func TestP2CBalancer(t *testing.T) {
+	instances := instancesTest(1, 1, 1, 1)
+	balancer := NewP2CBalancer()
+	// 选中的实例一直不结束请求，负载会被均摊到所有实例
+	counts := countSelect(t, balancer, instances, 400)
+	for _, instance := range instances {
+		assert.InDelta(t, 100, counts[instance.Ip], 10, instance.Ip)
+	}
+
+	// 请求立即结束时按权重分布
+	instances = instancesTest(1, 3)
+	balancer = NewP2CBalancer()
+	counts = map[string]int{}
+	for i := 0; i < 20000; i++ {
+		instance, _ := balancer.Select(serviceKeyTest, instances, "")
+		counts[instance.Ip]++
+		balancer.Done(serviceKeyTest, *instance)
+	}
+	assert.True(t, counts["10.0.0.2"] > counts["10.0.0.1"])
+}
+
+func TestConsistentHashBalancer(t *testing.T) {
+	instances := instancesTest(1, 1, 1, 1)
+	balancer := NewConsistentHashBalancer()
+
+	// 相同的 key 总是选中相同的实例
+	first, err := balancer.Select(serviceKeyTest, instances, "user-1")
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		instance, _ := balancer.Select(serviceKeyTest, instances, "user-1")
+		assert.Equal(t, first.Ip, instance.Ip)
+	}
+
+	// key 均匀分布
+	mapping := map[string]string{}
+	counts := map[string]int{}
+	for i := 0; i < 20000; i++ {
+		key := "user-" + strconv.Itoa(i)
+		instance, _ := balancer.Select(serviceKeyTest, instances, key)
+		mapping[key] = instance.Ip
+		counts[instance.Ip]++
+	}
+	assertRatio(t, counts, instances, 20000, 0.05)
+
+	// 下线一个实例时，只有原来映射到该实例的 key 会改变
+	removed := instances[0].Ip
+	moved := 0
+	for key, ip := range mapping {
+		instance, _ := balancer.Select(serviceKeyTest, instances[1:], key)
+		if ip != removed {
+			assert.Equal(t, ip, instance.Ip, key)
+		} else {
+			assert.NotEqual(t, removed, instance.Ip)
+			moved++
+		}
+	}
+	assert.Equal(t, counts[removed], moved)
+
+	// 调整一个实例的权重时，其它实例之间不会互相迁移 key
+	reweighted := instancesTest(1, 1, 1, 1)
+	reweighted[0].Weight = 2
+	for key, ip := range mapping {
+		instance, _ := balancer.Select(serviceKeyTest, reweighted, key)
+		if instance.Ip != ip {
+			assert.Equal(t, reweighted[0].Ip, instance.Ip, key)
+		}
+	}
+
+	// 返回的是本次传入的实例
+	instances[1].Metadata = map[string]string{"version": "2"}
+	for i := 0; i < 100; i++ {
+		instance, _ := balancer.Select(serviceKeyTest, instances, "user-"+strconv.Itoa(i))
+		if instance.Ip == instances[1].Ip {
+			assert.Equal(t, "2", instance.Metadata["version"])
+		}
+	}
+
+	// 虚拟节点数与权重成正比
+	instances = instancesTest(1, 3)
+	counts = map[string]int{}
+	for i := 0; i < 20000; i++ {
+		instance, _ := balancer.Select(serviceKeyTest, instances, "user-"+strconv.Itoa(i))
+		counts[instance.Ip]++
+	}
+	assertRatio(t, counts, instances, 20000, 0.05)
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const time_layout = "2006-01-02 15:04:05.000"
+
+// defaultLogger 按行输出 "时间 级别 消息 key=value ..."，out 为空时输出到标准错误
+type defaultLogger struct {
+	mutex  *sync.Mutex
+	out    io.Writer
+	level  *int32
+	fields []Field
+}
+
+// NewDefaultLogger 创建默认的文本 Logger，可配合 github.com/lestrrat/go-file-rotatelogs 输出到按时间切分的文件
+func NewDefaultLogger(out io.Writer, level Level) Logger {
+	return newDefaultLogger(out, level)
+}
+
+func newDefaultLogger(out io.Writer, level Level) *defaultLogger {
+	if out == nil {
+		out = os.Stderr
+	}
+	lvl := int32(level)
+	return &defaultLogger{
+		mutex: &sync.Mutex{},
+		out:   out,
+		level: &lvl,
+	}
+}
+
+func (l *defaultLogger) setLevel(level Level) {
+	atomic.StoreInt32(l.level, int32(level))
+}
+
+func (l *defaultLogger) enabled(level Level) bool {
+	return int32(level) >= atomic.LoadInt32(l.level)
+}
+
+func (l *defaultLogger) Debug(msg string, fields ...Field) {
+	l.log(DebugLevel, msg, fields)
+}
+
+func (l *defaultLogger) Info(msg string, fields ...Field) {
+	l.log(InfoLevel, msg, fields)
+}
+
+func (l *defaultLogger) Warn(msg string, fields ...Field) {
+	l.log(WarnLevel, msg, fields)
+}
+
+func (l *defaultLogger) Error(msg string, fields ...Field) {
+	l.log(ErrorLevel, msg, fields)
+}
+
+func (l *defaultLogger) With(fields ...Field) Logger {
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+	return &defaultLogger{
+		mutex:  l.mutex,
+		out:    l.out,
+		level:  l.level,
+		fields: all,
+	}
+}
+
+func (l *defaultLogger) log(level Level, msg string, fields []Field) {
+	if !l.enabled(level) {
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString(time.Now().Format(time_layout))
+	buf.WriteByte(' ')
+	buf.WriteString(level.String())
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+	writeFields(&buf, l.fields)
+	writeFields(&buf, fields)
+	buf.WriteByte('\n')
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.out.Write(buf.Bytes())
+}
+
+func writeFields(buf *bytes.Buffer, fields []Field) {
+	for _, field := range fields {
+		buf.WriteByte(' ')
+		buf.WriteString(field.Key)
+		buf.WriteByte('=')
+		buf.WriteString(formatValue(field.Value))
+	}
+}
+
+func formatValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -1,16 +1,169 @@
 package logger
 
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	rotatelogs "github.com/lestrrat/go-file-rotatelogs"
+)
+
+// Logger 是 sdk 内部使用的日志接口，可以通过 SetLogger 注入自定义实现（例如 zap、logrus 的适配器）
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	// With 返回一个带有固定字段的 Logger
+	With(fields ...Field) Logger
+}
+
+type Level int
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+const (
+	Default_Log_File_Name     = "nacos-sdk.log"
+	Default_Log_Rotation_Time = time.Hour
+	Default_Log_Max_Age       = 48 * time.Hour
+)
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARN"
+	case ErrorLevel:
+		return "ERROR"
+	}
+	return "UNKNOWN"
+}
+
+// ParseLevel 解析 debug/info/warn/error（不区分大小写），空字符串为 info
+func ParseLevel(level string) (Level, bool) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return DebugLevel, true
+	case "", "info":
+		return InfoLevel, true
+	case "warn", "warning":
+		return WarnLevel, true
+	case "error":
+		return ErrorLevel, true
+	}
+	return InfoLevel, false
+}
+
+// Field 结构化日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Err(err error) Field {
+	return Field{Key: "err", Value: err}
+}
+
+var (
+	mutex       sync.RWMutex
+	current     Logger = newDefaultLogger(nil, InfoLevel)
+	custom      bool
+	rotateDir   string
+	rotateOut   *rotatelogs.RotateLogs
+	defaultImpl *defaultLogger
+)
+
+// SetLogger 注入自定义的 Logger，之后 InitLog 不会再替换它
+func SetLogger(l Logger) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if l == nil {
+		return
+	}
+	current = l
+	custom = true
+}
+
+func GetLogger() Logger {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
 func InitLog(logDir string) error {
-	//err := util.MkdirIfNecessary(logDir)
-	//if err != nil {
-	//	return err
-	//}
-	//logDir = logDir + string(os.PathSeparator)
-	//rl, err := rotatelogs.New(filepath.Join(logDir, "nacos-sdk.log-%Y%m%d%H%M"), rotatelogs.WithRotationTime(time.Hour), rotatelogs.WithMaxAge(48*time.Hour), rotatelogs.WithLinkName(filepath.Join(logDir, "nacos-sdk.log")))
-	//if err != nil {
-	//	return err
-	//}
-	// log.SetOutput(rl)
-	// log.SetFlags(log.LstdFlags)
+	return InitLogWithLevel(logDir, "")
+}
+
+// InitLogWithLevel 在 logDir 下创建按小时切分、保留 48 小时的 nacos-sdk.log
+// 已经通过 SetLogger 注入自定义 Logger 时不做任何事情
+func InitLogWithLevel(logDir string, level string) error {
+	lvl, ok := ParseLevel(level)
+	if !ok {
+		return errors.New("[logger.InitLog] unknown log level:" + level)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if custom {
+		return nil
+	}
+	if rotateOut != nil && rotateDir == logDir {
+		defaultImpl.setLevel(lvl)
+		return nil
+	}
+	w, err := newRotateLogs(logDir, rotatelogs.Local)
+	if err != nil {
+		return err
+	}
+	if rotateOut != nil {
+		_ = rotateOut.Close()
+	}
+	rotateDir = logDir
+	rotateOut = w
+	defaultImpl = newDefaultLogger(w, lvl)
+	current = defaultImpl
 	return nil
 }
+
+// newRotateLogs 当前日志写入 logDir/nacos-sdk.log-yyyyMMddHHmm，nacos-sdk.log 链接到当前文件
+func newRotateLogs(logDir string, clock rotatelogs.Clock) (*rotatelogs.RotateLogs, error) {
+	if err := os.MkdirAll(logDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	logFile := filepath.Join(logDir, Default_Log_File_Name)
+	return rotatelogs.New(logFile+"-%Y%m%d%H%M",
+		rotatelogs.WithLinkName(logFile),
+		rotatelogs.WithRotationTime(Default_Log_Rotation_Time),
+		rotatelogs.WithMaxAge(Default_Log_Max_Age),
+		rotatelogs.WithClock(clock))
+}
+
+func Debug(msg string, fields ...Field) {
+	GetLogger().Debug(msg, fields...)
+}
+
+func Info(msg string, fields ...Field) {
+	GetLogger().Info(msg, fields...)
+}
+
+func Warn(msg string, fields ...Field) {
+	GetLogger().Warn(msg, fields...)
+}
+
+func Error(msg string, fields ...Field) {
+	GetLogger().Error(msg, fields...)
+}
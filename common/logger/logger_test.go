@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewDefaultLogger(&buf, WarnLevel)
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Contains(t, lines[0], " WARN warn")
+	assert.Contains(t, lines[1], " ERROR error")
+}
+
+func TestDefaultLogger_Fields(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewDefaultLogger(&buf, DebugLevel).With(F("namespaceId", "public"))
+	l.Info("beat failed", F("port", 8848), F("service", "DEFAULT_GROUP@@demo"), Err(errors.New("read timeout")))
+	assert.True(t, strings.HasSuffix(buf.String(),
+		" INFO beat failed namespaceId=public port=8848 service=DEFAULT_GROUP@@demo err=\"read timeout\"\n"))
+}
+
+func TestParseLevel(t *testing.T) {
+	level, ok := ParseLevel("")
+	assert.True(t, ok)
+	assert.Equal(t, InfoLevel, level)
+	level, ok = ParseLevel("DEBUG")
+	assert.True(t, ok)
+	assert.Equal(t, DebugLevel, level)
+	_, ok = ParseLevel("verbose")
+	assert.False(t, ok)
+}
+
+type recordLogger struct {
+	msgs []string
+}
+
+func (r *recordLogger) Debug(msg string, fields ...Field) { r.msgs = append(r.msgs, msg) }
+func (r *recordLogger) Info(msg string, fields ...Field)  { r.msgs = append(r.msgs, msg) }
+func (r *recordLogger) Warn(msg string, fields ...Field)  { r.msgs = append(r.msgs, msg) }
+func (r *recordLogger) Error(msg string, fields ...Field) { r.msgs = append(r.msgs, msg) }
+func (r *recordLogger) With(fields ...Field) Logger       { return r }
+
+func TestSetLogger(t *testing.T) {
+	old := GetLogger()
+	defer func() {
+		mutex.Lock()
+		current = old
+		custom = false
+		mutex.Unlock()
+	}()
+
+	r := &recordLogger{}
+	SetLogger(r)
+	dir, err := ioutil.TempDir("", "nacos-log")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	// 注入的 Logger 不会被 InitLog 替换
+	assert.Nil(t, InitLog(dir))
+	Error("failed")
+	assert.Equal(t, []string{"failed"}, r.msgs)
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestRotateLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nacos-log")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	clock := &testClock{now: time.Date(2020, 1, 1, 10, 30, 0, 0, time.Local)}
+	w, err := newRotateLogs(dir, clock)
+	assert.Nil(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("first\n"))
+	assert.Nil(t, err)
+	clock.now = clock.now.Add(time.Hour)
+	_, err = w.Write([]byte("second\n"))
+	assert.Nil(t, err)
+
+	rotated, err := ioutil.ReadFile(filepath.Join(dir, "nacos-sdk.log-202001011000"))
+	assert.Nil(t, err)
+	assert.Equal(t, "first\n", string(rotated))
+	// nacos-sdk.log 指向当前的日志文件
+	current, err := ioutil.ReadFile(filepath.Join(dir, "nacos-sdk.log"))
+	assert.Nil(t, err)
+	assert.Equal(t, "second\n", string(current))
+
+	// 超过 48 小时的文件在下一次切分时被删除
+	old := filepath.Join(dir, "nacos-sdk.log-201912281000")
+	assert.Nil(t, ioutil.WriteFile(old, []byte("old\n"), 0644))
+	assert.Nil(t, os.Chtimes(old, clock.now.Add(-72*time.Hour), clock.now.Add(-72*time.Hour)))
+	clock.now = clock.now.Add(time.Hour)
+	_, err = w.Write([]byte("third\n"))
+	assert.Nil(t, err)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, err = os.Stat(old); os.IsNotExist(err) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, os.IsNotExist(err))
+}
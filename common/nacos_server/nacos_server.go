@@ -1,6 +1,7 @@
 package nacos_server
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/base64"
@@ -18,10 +19,38 @@ import (
 	"github.com/satori/go.uuid"
 	"github.com/uugtv/nacos-sdk-go/common/constant"
 	"github.com/uugtv/nacos-sdk-go/common/http_agent"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/common/nacos_error"
 	"github.com/uugtv/nacos-sdk-go/utils"
 )
 
+// 打印日志时保留原值的请求参数，其余参数（配置内容、accessToken、密码等）的值会被隐藏
+var loggableParams = map[string]bool{
+	"dataId":      true,
+	"group":       true,
+	"tenant":      true,
+	"namespaceId": true,
+	"serviceName": true,
+	"groupName":   true,
+	"clusterName": true,
+	"clusters":    true,
+	"ip":          true,
+	"port":        true,
+}
+
+// redactParams 返回用于打印日志的参数副本
+func redactParams(params map[string]string) map[string]string {
+	redacted := make(map[string]string, len(params))
+	for key, value := range params {
+		if loggableParams[key] {
+			redacted[key] = value
+		} else {
+			redacted[key] = "***"
+		}
+	}
+	return redacted
+}
+
 type NacosServer struct {
 	sync.RWMutex
 	serverList          []constant.ServerConfig
@@ -47,7 +76,7 @@ func NewNacosServer(serverList []constant.ServerConfig, httpAgent http_agent.IHt
 	return ns, nil
 }
 
-func (server *NacosServer) callConfigServer(api string, params map[string]string, newHeaders map[string]string, method string, curServer string, contextPath string) (result string, err error) {
+func (server *NacosServer) callConfigServer(ctx context.Context, api string, params map[string]string, newHeaders map[string]string, method string, curServer string, contextPath string) (result string, err error) {
 	if contextPath == "" {
 		contextPath = constant.WEB_CONTEXT
 	}
@@ -69,7 +98,7 @@ func (server *NacosServer) callConfigServer(api string, params map[string]string
 	headers["Spas-Signature"] = []string{signHeaders["Spas-Signature"]}
 
 	var response *http.Response
-	response, err = server.httpAgent.Request(method, url, headers, server.timeoutMs, params)
+	response, err = server.httpAgent.RequestWithContext(ctx, method, url, headers, server.timeoutMs, params)
 	if err != nil {
 		return
 	}
@@ -88,7 +117,7 @@ func (server *NacosServer) callConfigServer(api string, params map[string]string
 	}
 }
 
-func (server *NacosServer) callServer(api string, params map[string]string, method string, curServer string, contextPath string) (result string, err error) {
+func (server *NacosServer) callServer(ctx context.Context, api string, params map[string]string, method string, curServer string, contextPath string) (result string, err error) {
 	if contextPath == "" {
 		contextPath = constant.WEB_CONTEXT
 	}
@@ -104,7 +133,7 @@ func (server *NacosServer) callServer(api string, params map[string]string, meth
 	headers["Content-Type"] = []string{"application/x-www-form-urlencoded;charset=UTF8"}
 
 	var response *http.Response
-	response, err = server.httpAgent.Request(method, url, headers, server.timeoutMs, params)
+	response, err = server.httpAgent.RequestWithContext(ctx, method, url, headers, server.timeoutMs, params)
 	if err != nil {
 		return
 	}
@@ -124,38 +153,60 @@ func (server *NacosServer) callServer(api string, params map[string]string, meth
 }
 
 func (server *NacosServer) ReqConfigApi(api string, params map[string]string, headers map[string]string, method string) (string, error) {
+	return server.ReqConfigApiWithContext(context.Background(), api, params, headers, method)
+}
+
+func (server *NacosServer) ReqConfigApiWithContext(ctx context.Context, api string, params map[string]string, headers map[string]string, method string) (string, error) {
+	result, _, err := server.ReqConfigApiWithServer(ctx, api, params, headers, method)
+	return result, err
+}
+
+// ReqConfigApiWithServer 同 ReqConfigApiWithContext，额外返回处理该请求的服务端地址
+func (server *NacosServer) ReqConfigApiWithServer(ctx context.Context, api string, params map[string]string, headers map[string]string, method string) (string, string, error) {
 	srvs := server.serverList
 	if srvs == nil || len(srvs) == 0 {
-		return "", errors.New("server list is empty")
+		return "", "", errors.New("server list is empty")
 	}
 	//only one server,retry request when error
 	var err error
 	var result string
 	if len(srvs) == 1 {
 		for i := 0; i < constant.REQUEST_DOMAIN_RETRY_TIME; i++ {
-			result, err = server.callConfigServer(api, params, headers, method, getAddress(srvs[0]), srvs[0].ContextPath)
+			result, err = server.callConfigServer(ctx, api, params, headers, method, getAddress(srvs[0]), srvs[0].ContextPath)
 			if err == nil {
-				return result, nil
+				return result, getAddress(srvs[0]), nil
+			}
+			if ctx.Err() != nil {
+				return "", "", ctx.Err()
 			}
-			// log.Printf("[ERROR] api<%s>,method:<%s>, params:<%s>, call domain error:<%s> , result:<%s> \n", api, method, utils.ToJsonString(params), err.Error(), result)
+			logger.Error("[NacosServer] call domain error", logger.F("api", api), logger.F("method", method),
+				logger.F("params", utils.ToJsonString(redactParams(params))), logger.Err(err), logger.F("result", result))
 		}
-		return "", err
+		return "", "", err
 	} else {
 		index := rand.Intn(len(srvs))
 		for i := 1; i <= len(srvs); i++ {
 			curServer := srvs[index]
-			result, err = server.callConfigServer(api, params, headers, method, getAddress(curServer), curServer.ContextPath)
+			result, err = server.callConfigServer(ctx, api, params, headers, method, getAddress(curServer), curServer.ContextPath)
 			if err == nil {
-				return result, nil
+				return result, getAddress(curServer), nil
+			}
+			if ctx.Err() != nil {
+				return "", "", ctx.Err()
 			}
-			// log.Printf("[ERROR] api<%s>,method:<%s>, params:<%s>, call domain error:<%s> , result:<%s> \n", api, method, utils.ToJsonString(params), err.Error(), result)
+			logger.Error("[NacosServer] call domain error", logger.F("api", api), logger.F("method", method),
+				logger.F("params", utils.ToJsonString(redactParams(params))), logger.Err(err), logger.F("result", result))
 			index = (index + i) % len(srvs)
 		}
-		return "", err
+		return "", "", err
 	}
 }
 
 func (server *NacosServer) ReqApi(api string, params map[string]string, method string) (string, error) {
+	return server.ReqApiWithContext(context.Background(), api, params, method)
+}
+
+func (server *NacosServer) ReqApiWithContext(ctx context.Context, api string, params map[string]string, method string) (string, error) {
 	srvs := server.serverList
 	if srvs == nil || len(srvs) == 0 {
 		return "", errors.New("server list is empty")
@@ -163,22 +214,30 @@ func (server *NacosServer) ReqApi(api string, params map[string]string, method s
 	//only one server,retry request when error
 	if len(srvs) == 1 {
 		for i := 0; i < constant.REQUEST_DOMAIN_RETRY_TIME; i++ {
-			result, err := server.callServer(api, params, method, getAddress(srvs[0]), srvs[0].ContextPath)
+			result, err := server.callServer(ctx, api, params, method, getAddress(srvs[0]), srvs[0].ContextPath)
 			if err == nil {
 				return result, nil
 			}
-			// log.Printf("[ERROR] api<%s>,method:<%s>, params:<%s>, call domain error:<%s> , result:<%s> \n", api, method, utils.ToJsonString(params), err.Error(), result)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			logger.Error("[NacosServer] call domain error", logger.F("api", api), logger.F("method", method),
+				logger.F("params", utils.ToJsonString(redactParams(params))), logger.Err(err), logger.F("result", result))
 		}
 		return "", errors.New("retry " + strconv.Itoa(constant.REQUEST_DOMAIN_RETRY_TIME) + " times request failed!")
 	} else {
 		index := rand.Intn(len(srvs))
 		for i := 1; i <= len(srvs); i++ {
 			curServer := srvs[index]
-			result, err := server.callServer(api, params, method, getAddress(curServer), curServer.ContextPath)
+			result, err := server.callServer(ctx, api, params, method, getAddress(curServer), curServer.ContextPath)
 			if err == nil {
 				return result, nil
 			}
-			// log.Printf("[ERROR] api<%s>,method:<%s>, params:<%s>, call domain error:<%s> , result:<%s> \n", api, method, utils.ToJsonString(params), err.Error(), result)
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			logger.Error("[NacosServer] call domain error", logger.F("api", api), logger.F("method", method),
+				logger.F("params", utils.ToJsonString(redactParams(params))), logger.Err(err), logger.F("result", result))
 			index = (index + i) % len(srvs)
 		}
 		return "", errors.New("retry " + strconv.Itoa(constant.REQUEST_DOMAIN_RETRY_TIME) + " times request failed!")
@@ -206,7 +265,7 @@ func (server *NacosServer) refreshServerSrvIfNeed() {
 	urlString := "http://" + server.endpoint + "/nacos/serverlist"
 	result := server.httpAgent.RequestOnlyResult(http.MethodGet, urlString, nil, server.timeoutMs, nil)
 	list = strings.Split(result, "\n")
-	// log.Printf("[info] http nacos server list: <%s> \n", result)
+	logger.Debug("[NacosServer] http nacos server list", logger.F("result", result))
 
 	var servers []constant.ServerConfig
 	for _, line := range list {
@@ -217,7 +276,7 @@ func (server *NacosServer) refreshServerSrvIfNeed() {
 			if len(splitLine) == 2 {
 				port, err = strconv.Atoi(splitLine[1])
 				if err != nil {
-					// log.Printf("[ERROR] get port from server:<%s>  error: <%s> \n", line, err.Error())
+					logger.Error("[NacosServer] get port from server error", logger.F("server", line), logger.Err(err))
 					continue
 				}
 			}
@@ -227,7 +286,7 @@ func (server *NacosServer) refreshServerSrvIfNeed() {
 	if len(servers) > 0 {
 		if !reflect.DeepEqual(server.serverList, servers) {
 			server.Lock()
-			// log.Printf("[info] server list is updated, old: <%v>,new:<%v> \n", server.serverList, servers)
+			logger.Info("[NacosServer] server list is updated", logger.F("old", server.serverList), logger.F("new", servers))
 			server.serverList = servers
 			server.lastSrvRefTime = utils.CurrentMillis()
 			server.Unlock()
@@ -0,0 +1,110 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// AesGcmProvider 使用本地密钥进行 AES-GCM 加解密，密文为 base64(nonce + 密文)
+type AesGcmProvider struct {
+	aead cipher.AEAD
+}
+
+// NewAesGcmProvider key 长度必须为 16、24 或 32 字节
+func NewAesGcmProvider(key []byte) (*AesGcmProvider, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.New("[security.AesGcm] invalid key:" + err.Error())
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AesGcmProvider{aead: aead}, nil
+}
+
+const (
+	Key_Prefix_Hex    = "hex:"
+	Key_Prefix_Base64 = "base64:"
+)
+
+// NewAesGcmProviderFromFile 从密钥文件加载，首尾空白会被忽略；
+// 内容以 hex: 或 base64: 开头时按对应编码解码，否则整个内容即为原始密钥
+func NewAesGcmProviderFromFile(path string) (*AesGcmProvider, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.New("[security.AesGcm] read key file failed:" + err.Error())
+	}
+	key, err := parseKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewAesGcmProvider(key)
+}
+
+func parseKey(data []byte) ([]byte, error) {
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(text, Key_Prefix_Hex):
+		key, err := hex.DecodeString(strings.TrimPrefix(text, Key_Prefix_Hex))
+		if err != nil {
+			return nil, errors.New("[security.AesGcm] invalid hex key:" + err.Error())
+		}
+		return key, nil
+	case strings.HasPrefix(text, Key_Prefix_Base64):
+		key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(text, Key_Prefix_Base64))
+		if err != nil {
+			return nil, errors.New("[security.AesGcm] invalid base64 key:" + err.Error())
+		}
+		return key, nil
+	default:
+		return []byte(text), nil
+	}
+}
+
+func (p *AesGcmProvider) Encrypt(dataId string, plainText string) (string, error) {
+	sealed, err := p.seal([]byte(plainText))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (p *AesGcmProvider) Decrypt(dataId string, cipherText string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(cipherText))
+	if err != nil {
+		return "", errors.New("[security.AesGcm] decrypt failed:" + err.Error())
+	}
+	plain, err := p.open(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (p *AesGcmProvider) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return p.aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+func (p *AesGcmProvider) open(sealed []byte) ([]byte, error) {
+	if len(sealed) < p.aead.NonceSize() {
+		return nil, errors.New("[security.AesGcm] decrypt failed:cipher text too short")
+	}
+	nonce := sealed[:p.aead.NonceSize()]
+	plain, err := p.aead.Open(nil, nonce, sealed[p.aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("[security.AesGcm] decrypt failed:" + err.Error())
+	}
+	return plain, nil
+}
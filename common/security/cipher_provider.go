@@ -0,0 +1,62 @@
+package security
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CipherProvider 对配置内容进行加解密，按 dataId 前缀注册到 Registry
+type CipherProvider interface {
+	Encrypt(dataId string, plainText string) (string, error)
+	Decrypt(dataId string, cipherText string) (string, error)
+}
+
+type providerEntry struct {
+	prefix   string
+	provider CipherProvider
+}
+
+// Registry 维护 dataId 前缀到 CipherProvider 的映射，多个前缀匹配时取最长的前缀
+type Registry struct {
+	mutex   sync.RWMutex
+	entries []providerEntry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register 注册前缀对应的 CipherProvider，相同前缀重复注册时覆盖，provider 为空时移除
+func (r *Registry) Register(prefix string, provider CipherProvider) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, entry := range r.entries {
+		if entry.prefix == prefix {
+			if provider == nil {
+				r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			} else {
+				r.entries[i].provider = provider
+			}
+			return
+		}
+	}
+	if provider == nil {
+		return
+	}
+	r.entries = append(r.entries, providerEntry{prefix: prefix, provider: provider})
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return len(r.entries[i].prefix) > len(r.entries[j].prefix)
+	})
+}
+
+func (r *Registry) Lookup(dataId string) (CipherProvider, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, entry := range r.entries {
+		if strings.HasPrefix(dataId, entry.prefix) {
+			return entry.provider, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,70 @@
+package security
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const data_key_size = 32
+
+// EnvelopeProvider 信封加密：每次加密生成随机数据密钥，用数据密钥 AES-GCM 加密内容，
+// 再用 keyProvider（本地密钥或 KMS）加密数据密钥，二者一起保存在密文中
+type EnvelopeProvider struct {
+	keyProvider CipherProvider
+}
+
+type envelope struct {
+	DataKey string `json:"dataKey"`
+	Content string `json:"content"`
+}
+
+func NewEnvelopeProvider(keyProvider CipherProvider) *EnvelopeProvider {
+	return &EnvelopeProvider{keyProvider: keyProvider}
+}
+
+func (p *EnvelopeProvider) Encrypt(dataId string, plainText string) (string, error) {
+	dataKey := make([]byte, data_key_size)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	contentProvider, err := NewAesGcmProvider(dataKey)
+	if err != nil {
+		return "", err
+	}
+	content, err := contentProvider.Encrypt(dataId, plainText)
+	if err != nil {
+		return "", err
+	}
+	encryptedKey, err := p.keyProvider.Encrypt(dataId, base64.StdEncoding.EncodeToString(dataKey))
+	if err != nil {
+		return "", errors.New("[security.Envelope] encrypt data key failed:" + err.Error())
+	}
+	result, err := json.Marshal(envelope{DataKey: encryptedKey, Content: content})
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+func (p *EnvelopeProvider) Decrypt(dataId string, cipherText string) (string, error) {
+	var env envelope
+	if err := json.Unmarshal([]byte(cipherText), &env); err != nil || env.DataKey == "" {
+		return "", errors.New("[security.Envelope] invalid envelope")
+	}
+	encodedKey, err := p.keyProvider.Decrypt(dataId, env.DataKey)
+	if err != nil {
+		return "", errors.New("[security.Envelope] decrypt data key failed:" + err.Error())
+	}
+	dataKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return "", errors.New("[security.Envelope] invalid data key")
+	}
+	contentProvider, err := NewAesGcmProvider(dataKey)
+	if err != nil {
+		return "", err
+	}
+	return contentProvider.Decrypt(dataId, env.Content)
+}
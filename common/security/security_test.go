@@ -0,0 +1,116 @@
+package security
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var keyTest = []byte("0123456789abcdef0123456789abcdef")
+
+func TestAesGcmProvider(t *testing.T) {
+	provider, err := NewAesGcmProvider(keyTest)
+	assert.Nil(t, err)
+
+	cipherText, err := provider.Encrypt("cipher-db", "password=secret")
+	assert.Nil(t, err)
+	assert.NotContains(t, cipherText, "secret")
+	other, _ := provider.Encrypt("cipher-db", "password=secret")
+	assert.NotEqual(t, cipherText, other)
+
+	plainText, err := provider.Decrypt("cipher-db", cipherText)
+	assert.Nil(t, err)
+	assert.Equal(t, "password=secret", plainText)
+
+	wrong, _ := NewAesGcmProvider([]byte("fedcba9876543210fedcba9876543210"))
+	_, err = wrong.Decrypt("cipher-db", cipherText)
+	assert.NotNil(t, err)
+	_, err = provider.Decrypt("cipher-db", "not base64!")
+	assert.NotNil(t, err)
+
+	_, err = NewAesGcmProvider([]byte("short"))
+	assert.NotNil(t, err)
+}
+
+func TestNewAesGcmProviderFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nacos-security")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	expected, _ := NewAesGcmProvider(keyTest)
+	cipherText, _ := expected.Encrypt("cipher-db", "content")
+	for name, data := range map[string]string{
+		"raw":    string(keyTest) + "\n",
+		"hex":    "hex:" + hex.EncodeToString(keyTest) + "\n",
+		"base64": " base64:" + base64.StdEncoding.EncodeToString(keyTest) + "\n",
+	} {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(data), 0600))
+		provider, err := NewAesGcmProviderFromFile(path)
+		assert.Nil(t, err, name)
+		plainText, err := provider.Decrypt("cipher-db", cipherText)
+		assert.Nil(t, err, name)
+		assert.Equal(t, "content", plainText, name)
+	}
+
+	_, err = NewAesGcmProviderFromFile(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+
+	// 不带前缀时不会按编码猜测，32 个 hex 字符作为原始密钥使用
+	path := filepath.Join(dir, "hex-without-prefix")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(hex.EncodeToString([]byte("0123456789abcdef"))), 0600))
+	provider, err := NewAesGcmProviderFromFile(path)
+	assert.Nil(t, err)
+	_, err = provider.Decrypt("cipher-db", cipherText)
+	assert.NotNil(t, err)
+
+	path = filepath.Join(dir, "invalid-hex")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("hex:xyz"), 0600))
+	_, err = NewAesGcmProviderFromFile(path)
+	assert.NotNil(t, err)
+}
+
+func TestEnvelopeProvider(t *testing.T) {
+	master, _ := NewAesGcmProvider(keyTest)
+	provider := NewEnvelopeProvider(master)
+
+	cipherText, err := provider.Encrypt("cipher-db", "password=secret")
+	assert.Nil(t, err)
+	assert.NotContains(t, cipherText, "secret")
+
+	plainText, err := provider.Decrypt("cipher-db", cipherText)
+	assert.Nil(t, err)
+	assert.Equal(t, "password=secret", plainText)
+
+	other, _ := NewAesGcmProvider([]byte("fedcba9876543210fedcba9876543210"))
+	_, err = NewEnvelopeProvider(other).Decrypt("cipher-db", cipherText)
+	assert.NotNil(t, err)
+	_, err = provider.Decrypt("cipher-db", "plain")
+	assert.NotNil(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	short, _ := NewAesGcmProvider(keyTest)
+	long := NewEnvelopeProvider(short)
+	registry.Register("cipher-", short)
+	registry.Register("cipher-kms-", long)
+
+	provider, ok := registry.Lookup("cipher-db")
+	assert.True(t, ok)
+	assert.Equal(t, CipherProvider(short), provider)
+	provider, ok = registry.Lookup("cipher-kms-db")
+	assert.True(t, ok)
+	assert.Equal(t, CipherProvider(long), provider)
+	_, ok = registry.Lookup("db")
+	assert.False(t, ok)
+
+	registry.Register("cipher-kms-", nil)
+	provider, _ = registry.Lookup("cipher-kms-db")
+	assert.Equal(t, CipherProvider(short), provider)
+}
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 )
 
 /**
@@ -48,7 +50,7 @@ func TransformObject2Param(object interface{}) (params map[string]string) {
 					if !valueOf.Field(i).IsNil() {
 						bytes, err := json.Marshal(valueOf.Field(i).Interface())
 						if err != nil {
-							// log.Println("[TransformObject2Param]", err)
+							logger.Error("[TransformObject2Param] marshal field error", logger.F("field", tag), logger.Err(err))
 						} else {
 							params[tag] = string(bytes)
 						}
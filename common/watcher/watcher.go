@@ -0,0 +1,41 @@
+package watcher
+
+import (
+	"sync"
+)
+
+// Default_Watch_Chan_Size WatchConfig 和 Watch 返回的 channel 的缓冲大小
+const Default_Watch_Chan_Size = 16
+
+// Guard 保护监听 channel 的写入和关闭：关闭时先让正在进行的写入退出，之后的写入直接忽略，避免写入已关闭的 channel
+type Guard struct {
+	mutex  sync.RWMutex
+	closed bool
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewGuard() *Guard {
+	return &Guard{done: make(chan struct{})}
+}
+
+// Send channel 未关闭时调用 send，阻塞写入的 send 需要同时等待 done，以便关闭时退出
+func (g *Guard) Send(send func(done <-chan struct{})) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	if g.closed {
+		return
+	}
+	send(g.done)
+}
+
+// Close 让正在进行的写入退出后调用 closeChan 关闭 channel，重复调用只生效一次
+func (g *Guard) Close(closeChan func()) {
+	g.once.Do(func() {
+		close(g.done)
+		g.mutex.Lock()
+		defer g.mutex.Unlock()
+		g.closed = true
+		closeChan()
+	})
+}
@@ -0,0 +1,34 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuard(t *testing.T) {
+	guard := NewGuard()
+	events := make(chan int)
+	sent := make(chan bool, 1)
+	// 阻塞中的写入在关闭时退出
+	go guard.Send(func(done <-chan struct{}) {
+		select {
+		case events <- 1:
+			sent <- true
+		case <-done:
+			sent <- false
+		}
+	})
+	time.Sleep(10 * time.Millisecond)
+	guard.Close(func() { close(events) })
+	guard.Close(func() { close(events) })
+	assert.False(t, <-sent)
+	_, ok := <-events
+	assert.False(t, ok)
+
+	// 关闭后的写入被忽略
+	called := false
+	guard.Send(func(done <-chan struct{}) { called = true })
+	assert.False(t, called)
+}
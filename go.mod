@@ -3,6 +3,7 @@ module github.com/uugtv/nacos-sdk-go
 go 1.13
 
 require (
+	github.com/BurntSushi/toml v0.3.0
 	github.com/aliyun/alibaba-cloud-sdk-go v0.0.0-20190705062259-e6958b522d6f
 	github.com/buger/jsonparser v0.0.0-20181115193947-bf1c66bbce23
 	github.com/davecgh/go-spew v1.1.1
@@ -18,5 +19,5 @@ require (
 	github.com/pmezard/go-difflib v1.0.0
 	github.com/satori/go.uuid v1.2.0
 	github.com/stretchr/testify v1.3.0
-	github.com/toolkits/concurrent v0.0.0-20150624120057-a4371d70e3e3
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -5,7 +5,10 @@
 package mock
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
+	security "github.com/uugtv/nacos-sdk-go/common/security"
+	model "github.com/uugtv/nacos-sdk-go/model"
 	vo "github.com/uugtv/nacos-sdk-go/vo"
 	reflect "reflect"
 )
@@ -48,6 +51,50 @@ func (mr *MockIConfigClientMockRecorder) GetConfig(param interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockIConfigClient)(nil).GetConfig), param)
 }
 
+// GetConfigWithContext mocks base method
+func (m *MockIConfigClient) GetConfigWithContext(ctx context.Context, param vo.ConfigParam) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigWithContext", ctx, param)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigWithContext indicates an expected call of GetConfigWithContext
+func (mr *MockIConfigClientMockRecorder) GetConfigWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigWithContext", reflect.TypeOf((*MockIConfigClient)(nil).GetConfigWithContext), ctx, param)
+}
+
+// GetConfigInto mocks base method
+func (m *MockIConfigClient) GetConfigInto(param vo.ConfigParam, target interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigInto", param, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetConfigInto indicates an expected call of GetConfigInto
+func (mr *MockIConfigClientMockRecorder) GetConfigInto(param, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigInto", reflect.TypeOf((*MockIConfigClient)(nil).GetConfigInto), param, target)
+}
+
+// GetConfigInfo mocks base method
+func (m *MockIConfigClient) GetConfigInfo(param vo.ConfigParam) (model.ConfigInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigInfo", param)
+	ret0, _ := ret[0].(model.ConfigInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigInfo indicates an expected call of GetConfigInfo
+func (mr *MockIConfigClientMockRecorder) GetConfigInfo(param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigInfo", reflect.TypeOf((*MockIConfigClient)(nil).GetConfigInfo), param)
+}
+
 // PublishConfig mocks base method
 func (m *MockIConfigClient) PublishConfig(param vo.ConfigParam) (bool, error) {
 	m.ctrl.T.Helper()
@@ -63,6 +110,21 @@ func (mr *MockIConfigClientMockRecorder) PublishConfig(param interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishConfig", reflect.TypeOf((*MockIConfigClient)(nil).PublishConfig), param)
 }
 
+// PublishConfigWithContext mocks base method
+func (m *MockIConfigClient) PublishConfigWithContext(ctx context.Context, param vo.ConfigParam) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishConfigWithContext", ctx, param)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishConfigWithContext indicates an expected call of PublishConfigWithContext
+func (mr *MockIConfigClientMockRecorder) PublishConfigWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishConfigWithContext", reflect.TypeOf((*MockIConfigClient)(nil).PublishConfigWithContext), ctx, param)
+}
+
 // DeleteConfig mocks base method
 func (m *MockIConfigClient) DeleteConfig(param vo.ConfigParam) (bool, error) {
 	m.ctrl.T.Helper()
@@ -78,8 +140,23 @@ func (mr *MockIConfigClientMockRecorder) DeleteConfig(param interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConfig", reflect.TypeOf((*MockIConfigClient)(nil).DeleteConfig), param)
 }
 
+// DeleteConfigWithContext mocks base method
+func (m *MockIConfigClient) DeleteConfigWithContext(ctx context.Context, param vo.ConfigParam) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteConfigWithContext", ctx, param)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteConfigWithContext indicates an expected call of DeleteConfigWithContext
+func (mr *MockIConfigClientMockRecorder) DeleteConfigWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConfigWithContext", reflect.TypeOf((*MockIConfigClient)(nil).DeleteConfigWithContext), ctx, param)
+}
+
 // ListenConfig mocks base method
-func (m *MockIConfigClient) ListenConfig(params []vo.ConfigParam) error {
+func (m *MockIConfigClient) ListenConfig(params vo.ConfigParam) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ListenConfig", params)
 	ret0, _ := ret[0].(error)
@@ -92,43 +169,57 @@ func (mr *MockIConfigClientMockRecorder) ListenConfig(params interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenConfig", reflect.TypeOf((*MockIConfigClient)(nil).ListenConfig), params)
 }
 
-// AddConfigToListen mocks base method
-func (m *MockIConfigClient) AddConfigToListen(params []vo.ConfigParam) error {
+// CancelListenConfig mocks base method
+func (m *MockIConfigClient) CancelListenConfig(params vo.ConfigParam) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddConfigToListen", params)
+	ret := m.ctrl.Call(m, "CancelListenConfig", params)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// AddConfigToListen indicates an expected call of AddConfigToListen
-func (mr *MockIConfigClientMockRecorder) AddConfigToListen(params interface{}) *gomock.Call {
+// CancelListenConfig indicates an expected call of CancelListenConfig
+func (mr *MockIConfigClientMockRecorder) CancelListenConfig(params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddConfigToListen", reflect.TypeOf((*MockIConfigClient)(nil).AddConfigToListen), params)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelListenConfig", reflect.TypeOf((*MockIConfigClient)(nil).CancelListenConfig), params)
 }
 
-// StopListenConfig mocks base method
-func (m *MockIConfigClient) StopListenConfig() {
+// WatchConfig mocks base method
+func (m *MockIConfigClient) WatchConfig(ctx context.Context, params vo.ConfigParam) (<-chan model.ConfigChangeEvent, error) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "StopListenConfig")
+	ret := m.ctrl.Call(m, "WatchConfig", ctx, params)
+	ret0, _ := ret[0].(<-chan model.ConfigChangeEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// StopListenConfig indicates an expected call of StopListenConfig
-func (mr *MockIConfigClientMockRecorder) StopListenConfig() *gomock.Call {
+// WatchConfig indicates an expected call of WatchConfig
+func (mr *MockIConfigClientMockRecorder) WatchConfig(ctx, params interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopListenConfig", reflect.TypeOf((*MockIConfigClient)(nil).StopListenConfig))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchConfig", reflect.TypeOf((*MockIConfigClient)(nil).WatchConfig), ctx, params)
 }
 
-// GetConfigContent mocks base method
-func (m *MockIConfigClient) GetConfigContent(dataId, groupId string) (string, error) {
+// RegisterCipherProvider mocks base method
+func (m *MockIConfigClient) RegisterCipherProvider(prefix string, provider security.CipherProvider) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetConfigContent", dataId, groupId)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	m.ctrl.Call(m, "RegisterCipherProvider", prefix, provider)
+}
+
+// RegisterCipherProvider indicates an expected call of RegisterCipherProvider
+func (mr *MockIConfigClientMockRecorder) RegisterCipherProvider(prefix, provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterCipherProvider", reflect.TypeOf((*MockIConfigClient)(nil).RegisterCipherProvider), prefix, provider)
+}
+
+// Close mocks base method
+func (m *MockIConfigClient) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetConfigContent indicates an expected call of GetConfigContent
-func (mr *MockIConfigClientMockRecorder) GetConfigContent(dataId, groupId interface{}) *gomock.Call {
+// Close indicates an expected call of Close
+func (mr *MockIConfigClientMockRecorder) Close() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigContent", reflect.TypeOf((*MockIConfigClient)(nil).GetConfigContent), dataId, groupId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockIConfigClient)(nil).Close))
 }
@@ -5,6 +5,7 @@
 package mock
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/uugtv/nacos-sdk-go/model"
 	vo "github.com/uugtv/nacos-sdk-go/vo"
@@ -64,6 +65,21 @@ func (mr *MockINamingClientMockRecorder) DeregisterInstance(param interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeregisterInstance", reflect.TypeOf((*MockINamingClient)(nil).DeregisterInstance), param)
 }
 
+// UpdateInstance mocks base method
+func (m *MockINamingClient) UpdateInstance(param vo.UpdateInstanceParam) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInstance", param)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateInstance indicates an expected call of UpdateInstance
+func (mr *MockINamingClientMockRecorder) UpdateInstance(param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInstance", reflect.TypeOf((*MockINamingClient)(nil).UpdateInstance), param)
+}
+
 // GetService mocks base method
 func (m *MockINamingClient) GetService(param vo.GetServiceParam) (model.Service, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +95,21 @@ func (mr *MockINamingClientMockRecorder) GetService(param interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetService", reflect.TypeOf((*MockINamingClient)(nil).GetService), param)
 }
 
+// SelectAllInstances mocks base method
+func (m *MockINamingClient) SelectAllInstances(param vo.SelectAllInstancesParam) ([]model.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAllInstances", param)
+	ret0, _ := ret[0].([]model.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAllInstances indicates an expected call of SelectAllInstances
+func (mr *MockINamingClientMockRecorder) SelectAllInstances(param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAllInstances", reflect.TypeOf((*MockINamingClient)(nil).SelectAllInstances), param)
+}
+
 // SelectInstances mocks base method
 func (m *MockINamingClient) SelectInstances(param vo.SelectInstancesParam) ([]model.Instance, error) {
 	m.ctrl.T.Helper()
@@ -110,11 +141,12 @@ func (mr *MockINamingClientMockRecorder) SelectOneHealthyInstance(param interfac
 }
 
 // Subscribe mocks base method
-func (m *MockINamingClient) Subscribe(param *vo.SubscribeParam) error {
+func (m *MockINamingClient) Subscribe(param *vo.SubscribeParam) (model.Subscription, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Subscribe", param)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(model.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Subscribe indicates an expected call of Subscribe
@@ -136,3 +168,243 @@ func (mr *MockINamingClientMockRecorder) Unsubscribe(param interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockINamingClient)(nil).Unsubscribe), param)
 }
+
+// Watch mocks base method
+func (m *MockINamingClient) Watch(ctx context.Context, param vo.SubscribeParam) (<-chan model.ServiceEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, param)
+	ret0, _ := ret[0].(<-chan model.ServiceEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch
+func (mr *MockINamingClientMockRecorder) Watch(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockINamingClient)(nil).Watch), ctx, param)
+}
+
+// GetAllServicesInfo mocks base method
+func (m *MockINamingClient) GetAllServicesInfo(param vo.GetAllServiceInfoParam) ([]model.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllServicesInfo", param)
+	ret0, _ := ret[0].([]model.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllServicesInfo indicates an expected call of GetAllServicesInfo
+func (mr *MockINamingClientMockRecorder) GetAllServicesInfo(param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllServicesInfo", reflect.TypeOf((*MockINamingClient)(nil).GetAllServicesInfo), param)
+}
+
+// RegisterInstanceWithContext mocks base method
+func (m *MockINamingClient) RegisterInstanceWithContext(ctx context.Context, param vo.RegisterInstanceParam) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterInstanceWithContext", ctx, param)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterInstanceWithContext indicates an expected call of RegisterInstanceWithContext
+func (mr *MockINamingClientMockRecorder) RegisterInstanceWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterInstanceWithContext", reflect.TypeOf((*MockINamingClient)(nil).RegisterInstanceWithContext), ctx, param)
+}
+
+// DeregisterInstanceWithContext mocks base method
+func (m *MockINamingClient) DeregisterInstanceWithContext(ctx context.Context, param vo.DeregisterInstanceParam) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeregisterInstanceWithContext", ctx, param)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeregisterInstanceWithContext indicates an expected call of DeregisterInstanceWithContext
+func (mr *MockINamingClientMockRecorder) DeregisterInstanceWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeregisterInstanceWithContext", reflect.TypeOf((*MockINamingClient)(nil).DeregisterInstanceWithContext), ctx, param)
+}
+
+// UpdateInstanceWithContext mocks base method
+func (m *MockINamingClient) UpdateInstanceWithContext(ctx context.Context, param vo.UpdateInstanceParam) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInstanceWithContext", ctx, param)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateInstanceWithContext indicates an expected call of UpdateInstanceWithContext
+func (mr *MockINamingClientMockRecorder) UpdateInstanceWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInstanceWithContext", reflect.TypeOf((*MockINamingClient)(nil).UpdateInstanceWithContext), ctx, param)
+}
+
+// GetServiceWithContext mocks base method
+func (m *MockINamingClient) GetServiceWithContext(ctx context.Context, param vo.GetServiceParam) (model.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceWithContext", ctx, param)
+	ret0, _ := ret[0].(model.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceWithContext indicates an expected call of GetServiceWithContext
+func (mr *MockINamingClientMockRecorder) GetServiceWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceWithContext", reflect.TypeOf((*MockINamingClient)(nil).GetServiceWithContext), ctx, param)
+}
+
+// SelectAllInstancesWithContext mocks base method
+func (m *MockINamingClient) SelectAllInstancesWithContext(ctx context.Context, param vo.SelectAllInstancesParam) ([]model.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectAllInstancesWithContext", ctx, param)
+	ret0, _ := ret[0].([]model.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectAllInstancesWithContext indicates an expected call of SelectAllInstancesWithContext
+func (mr *MockINamingClientMockRecorder) SelectAllInstancesWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectAllInstancesWithContext", reflect.TypeOf((*MockINamingClient)(nil).SelectAllInstancesWithContext), ctx, param)
+}
+
+// SelectInstancesWithContext mocks base method
+func (m *MockINamingClient) SelectInstancesWithContext(ctx context.Context, param vo.SelectInstancesParam) ([]model.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectInstancesWithContext", ctx, param)
+	ret0, _ := ret[0].([]model.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectInstancesWithContext indicates an expected call of SelectInstancesWithContext
+func (mr *MockINamingClientMockRecorder) SelectInstancesWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectInstancesWithContext", reflect.TypeOf((*MockINamingClient)(nil).SelectInstancesWithContext), ctx, param)
+}
+
+// SelectOneHealthyInstanceWithContext mocks base method
+func (m *MockINamingClient) SelectOneHealthyInstanceWithContext(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectOneHealthyInstanceWithContext", ctx, param)
+	ret0, _ := ret[0].(*model.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SelectOneHealthyInstanceWithContext indicates an expected call of SelectOneHealthyInstanceWithContext
+func (mr *MockINamingClientMockRecorder) SelectOneHealthyInstanceWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectOneHealthyInstanceWithContext", reflect.TypeOf((*MockINamingClient)(nil).SelectOneHealthyInstanceWithContext), ctx, param)
+}
+
+// SelectOneHealthyInstanceWithLocality mocks base method
+func (m *MockINamingClient) SelectOneHealthyInstanceWithLocality(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectOneHealthyInstanceWithLocality", ctx, param)
+	ret0, _ := ret[0].(*model.Instance)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SelectOneHealthyInstanceWithLocality indicates an expected call of SelectOneHealthyInstanceWithLocality
+func (mr *MockINamingClientMockRecorder) SelectOneHealthyInstanceWithLocality(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectOneHealthyInstanceWithLocality", reflect.TypeOf((*MockINamingClient)(nil).SelectOneHealthyInstanceWithLocality), ctx, param)
+}
+
+// SelectOneHealthyInstanceWithRelease mocks base method
+func (m *MockINamingClient) SelectOneHealthyInstanceWithRelease(ctx context.Context, param vo.SelectOneHealthInstanceParam) (*model.Instance, string, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SelectOneHealthyInstanceWithRelease", ctx, param)
+	ret0, _ := ret[0].(*model.Instance)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(func())
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// SelectOneHealthyInstanceWithRelease indicates an expected call of SelectOneHealthyInstanceWithRelease
+func (mr *MockINamingClientMockRecorder) SelectOneHealthyInstanceWithRelease(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SelectOneHealthyInstanceWithRelease", reflect.TypeOf((*MockINamingClient)(nil).SelectOneHealthyInstanceWithRelease), ctx, param)
+}
+
+// GetAllServicesInfoWithContext mocks base method
+func (m *MockINamingClient) GetAllServicesInfoWithContext(ctx context.Context, param vo.GetAllServiceInfoParam) ([]model.Service, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllServicesInfoWithContext", ctx, param)
+	ret0, _ := ret[0].([]model.Service)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllServicesInfoWithContext indicates an expected call of GetAllServicesInfoWithContext
+func (mr *MockINamingClientMockRecorder) GetAllServicesInfoWithContext(ctx, param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllServicesInfoWithContext", reflect.TypeOf((*MockINamingClient)(nil).GetAllServicesInfoWithContext), ctx, param)
+}
+
+// GetBeatStatus mocks base method
+func (m *MockINamingClient) GetBeatStatus(param vo.GetBeatStatusParam) (model.BeatStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBeatStatus", param)
+	ret0, _ := ret[0].(model.BeatStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBeatStatus indicates an expected call of GetBeatStatus
+func (mr *MockINamingClientMockRecorder) GetBeatStatus(param interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBeatStatus", reflect.TypeOf((*MockINamingClient)(nil).GetBeatStatus), param)
+}
+
+// GetTrackedServiceCount mocks base method
+func (m *MockINamingClient) GetTrackedServiceCount() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrackedServiceCount")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GetTrackedServiceCount indicates an expected call of GetTrackedServiceCount
+func (mr *MockINamingClientMockRecorder) GetTrackedServiceCount() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrackedServiceCount", reflect.TypeOf((*MockINamingClient)(nil).GetTrackedServiceCount))
+}
+
+// GetDispatcherMetrics mocks base method
+func (m *MockINamingClient) GetDispatcherMetrics() model.DispatcherMetrics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDispatcherMetrics")
+	ret0, _ := ret[0].(model.DispatcherMetrics)
+	return ret0
+}
+
+// GetDispatcherMetrics indicates an expected call of GetDispatcherMetrics
+func (mr *MockINamingClientMockRecorder) GetDispatcherMetrics() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDispatcherMetrics", reflect.TypeOf((*MockINamingClient)(nil).GetDispatcherMetrics))
+}
+
+// Close mocks base method
+func (m *MockINamingClient) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close
+func (mr *MockINamingClientMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockINamingClient)(nil).Close))
+}
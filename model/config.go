@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type ConfigSource string
+
+const (
+	CONFIG_SOURCE_SERVER   ConfigSource = "server"
+	CONFIG_SOURCE_CACHE    ConfigSource = "cache"
+	CONFIG_SOURCE_FAILOVER ConfigSource = "failover"
+)
+
+// ConfigInfo 客户端当前持有的某个配置的元信息
+type ConfigInfo struct {
+	DataId       string       `json:"dataId"`
+	Group        string       `json:"group"`
+	Tenant       string       `json:"tenant"`
+	Md5          string       `json:"md5"`
+	LastModified time.Time    `json:"lastModified"` // 客户端最近一次观察到内容变化的时间
+	Server       string       `json:"server"`       // 提供该配置的服务端地址，来自本地缓存或故障转移文件时为空
+	Source       ConfigSource `json:"source"`
+}
+
+// ConfigChangeEvent 配置变更事件，Content 为解密后的内容
+// 监听时设置了 Target 时 Value 为解码后的新对象，解码失败时 Value 为 nil、Err 不为空
+type ConfigChangeEvent struct {
+	Namespace string
+	Group     string
+	DataId    string
+	Content   string
+	Value     interface{}
+	Err       error
+}
@@ -98,6 +98,14 @@ type SubscribeService struct {
 	Weight      float64           `json:"weight"`
 }
 
+// 服务变更事件：Service 为变更后的全量快照，Added、Removed、Modified 为相对上一次的实例变化
+type ServiceEvent struct {
+	Service  Service    `json:"service"`
+	Added    []Instance `json:"added"`
+	Removed  []Instance `json:"removed"`
+	Modified []Instance `json:"modified"`
+}
+
 type BeatInfo struct {
 	Ip          string            `json:"ip"`
 	Port        uint64            `json:"port"`
@@ -108,6 +116,33 @@ type BeatInfo struct {
 	Scheduled   bool              `json:"scheduled"`
 	Period      time.Duration     `json:"-"`
 	Stopped     bool              `json:"-"`
+	// 服务端允许轻量心跳，心跳请求不携带实例信息
+	LightBeatEnabled bool `json:"-"`
+}
+
+// BeatResult 服务端对心跳的响应，Code 为 constant.RESOURCE_NOT_FOUND_CODE 时表示服务端没有该实例
+type BeatResult struct {
+	ClientBeatInterval int64 `json:"clientBeatInterval"`
+	Code               int   `json:"code"`
+	LightBeatEnabled   bool  `json:"lightBeatEnabled"`
+}
+
+// BeatStatus 实例心跳的状态，LastSuccess 为最近一次心跳成功的时间（从未成功时为零值），ConsecutiveFailures 为连续失败的次数
+type BeatStatus struct {
+	LastSuccess         time.Time
+	ConsecutiveFailures int
+	Period              time.Duration
+}
+
+// InstanceEvent 本客户端注册的实例发生的事件，Type 为 constant.INSTANCE_EVENT_*，处理失败时 Err 不为空
+type InstanceEvent struct {
+	Type        string `json:"type"`
+	ServiceName string `json:"serviceName"`
+	GroupName   string `json:"groupName"`
+	Ip          string `json:"ip"`
+	Port        uint64 `json:"port"`
+	ClusterName string `json:"clusterName"`
+	Err         error  `json:"-"`
 }
 
 type ExpressionSelector struct {
@@ -119,3 +154,24 @@ type ServiceList struct {
 	Count int64    `json:"count"`
 	Doms  []string `json:"doms"`
 }
+
+// DispatcherMetrics 订阅回调的投递情况，QueueDepths 按服务统计待投递的通知数
+type DispatcherMetrics struct {
+	Subscribers   int
+	QueueDepth    int
+	MaxQueueDepth int
+	QueueDepths   map[string]int
+	Dropped       uint64
+	Coalesced     uint64
+	Panics        uint64
+}
+
+// Subscription Subscribe 返回的订阅句柄
+type Subscription interface {
+	// 取消订阅，重复调用无影响；服务的最后一个订阅取消后停止后台刷新该服务
+	Unsubscribe() error
+	// 当前的服务快照，订阅时指定了 Selector 时只包含匹配的实例
+	Service() Service
+	// 最近一次刷新服务失败的原因，刷新成功后为 nil；取消订阅后返回错误
+	Err() error
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/uugtv/nacos-sdk-go/common/constant"
+	"github.com/uugtv/nacos-sdk-go/common/logger"
 	"github.com/uugtv/nacos-sdk-go/model"
 )
 
@@ -36,7 +37,7 @@ func TryDecompressData(data []byte) string {
 	reader, err := gzip.NewReader(bytes.NewReader(data))
 
 	if err != nil {
-		// log.Printf("[ERROR]:failed to decompress gzip data,err:%s \n", err.Error())
+		logger.Error("failed to decompress gzip data", logger.Err(err))
 		return ""
 	}
 
@@ -44,7 +45,7 @@ func TryDecompressData(data []byte) string {
 	bs, err1 := ioutil.ReadAll(reader)
 
 	if err1 != nil {
-		// log.Printf("[ERROR]:failed to decompress gzip data,err:%s \n", err1.Error())
+		logger.Error("failed to decompress gzip data", logger.Err(err1))
 		return ""
 	}
 
@@ -63,11 +64,11 @@ func JsonToService(result string) *model.Service {
 	var service model.Service
 	err := json.Unmarshal([]byte(result), &service)
 	if err != nil {
-		// log.Printf("[ERROR]:failed to unmarshal json string:%s err:%v \n", result, err.Error())
+		logger.Error("failed to unmarshal json string", logger.F("json", result), logger.Err(err))
 		return nil
 	}
 	if len(service.Hosts) == 0 {
-		// log.Printf("[WARN]:instance list is empty,json string:%s \n", result)
+		logger.Warn("instance list is empty", logger.F("json", result))
 	}
 	return &service
 
@@ -109,14 +110,14 @@ func LocalIP() string {
 	if localIP == "" {
 		addrs, err := net.InterfaceAddrs()
 		if err != nil {
-			// log.Printf("[ERROR]:get InterfaceAddres failed,err:%s \n", err.Error())
+			logger.Error("get InterfaceAddres failed", logger.Err(err))
 			return ""
 		}
 		for _, address := range addrs {
 			if ipnet, ok := address.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
 				if ipnet.IP.To4() != nil {
 					localIP = ipnet.IP.String()
-					// log.Printf("InitLocalIp, LocalIp:%s \n", localIP)
+					logger.Info("InitLocalIp", logger.F("localIp", localIP))
 					break
 				}
 			}
@@ -130,7 +131,7 @@ func GetDurationWithDefault(metadata map[string]string, key string, defaultDurat
 	if ok {
 		value, err := strconv.ParseInt(data, 10, 64)
 		if err != nil {
-			// log.Printf("key:%s is not a number \n", key)
+			logger.Warn("metadata value is not a number", logger.F("key", key), logger.F("value", data))
 			return defaultDuration
 		}
 		return time.Duration(value)
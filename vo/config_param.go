@@ -16,4 +16,11 @@ type ConfigParam struct {
 	Tag      string `param:"tag"`
 	AppName  string `param:"appName"`
 	OnChange func(namespace, group, dataId, data string)
+
+	// 配置格式：json、yaml、toml、properties，为空时根据 dataId 后缀和内容识别
+	Format string
+	// 类型化监听的目标类型，传入结构体（或 map）指针；每次变更都会解码到一个新的同类型对象
+	Target interface{}
+	// 类型化监听，value 为解码后的新对象（与 Target 同类型的指针），内容无法解码时 value 为 nil、err 不为空
+	OnTypedChange func(namespace, group, dataId string, value interface{}, err error)
 }
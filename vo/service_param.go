@@ -1,6 +1,9 @@
 package vo
 
-import "github.com/uugtv/nacos-sdk-go/model"
+import (
+	"github.com/uugtv/nacos-sdk-go/common/loadbalancer"
+	"github.com/uugtv/nacos-sdk-go/model"
+)
 
 /**
 *
@@ -23,6 +26,9 @@ type RegisterInstanceParam struct {
 	ServiceName string            `param:"serviceName"`
 	GroupName   string            `param:"groupName"`
 	Ephemeral   bool              `param:"ephemeral"`
+	// 实例事件回调：服务端报告实例不存在、客户端重新注册后回调 constant.INSTANCE_EVENT_REREGISTERED 事件，
+	// 心跳连续失败 ClientConfig.BeatFailureThreshold 次时回调 constant.INSTANCE_EVENT_BEAT_FAILED 事件，恢复后回调 constant.INSTANCE_EVENT_RECOVERED 事件
+	OnEvent func(event model.InstanceEvent)
 }
 
 type DeregisterInstanceParam struct {
@@ -35,6 +41,27 @@ type DeregisterInstanceParam struct {
 	Ephemeral   bool   `param:"ephemeral"`
 }
 
+// UpdateInstanceParam 按实例的 ip、port 更新实例信息，Weight、Metadata 按传入的值整体覆盖
+// Enable 为空时保持本客户端注册实例时的值，不是本客户端注册的实例默认为 true
+type UpdateInstanceParam struct {
+	Ip          string            `param:"ip"`
+	Port        uint64            `param:"port"`
+	Weight      float64           `param:"weight"`
+	Enable      *bool             `param:"enabled"`
+	Metadata    map[string]string `param:"metadata"`
+	ClusterName string            `param:"clusterName"`
+	ServiceName string            `param:"serviceName"`
+	GroupName   string            `param:"groupName"`
+	Ephemeral   bool              `param:"ephemeral"`
+}
+
+type GetBeatStatusParam struct {
+	ServiceName string `param:"serviceName"`
+	GroupName   string `param:"groupName"`
+	Ip          string `param:"ip"`
+	Port        uint64 `param:"port"`
+}
+
 type GetServiceParam struct {
 	Clusters    []string `param:"clusters"`
 	ServiceName string   `param:"serviceName"`
@@ -72,6 +99,11 @@ type SubscribeParam struct {
 	Clusters          []string `param:"clusters"`
 	GroupName         string   `param:"groupName"`
 	SubscribeCallback func(services []model.SubscribeService, err error)
+	// 服务变更事件回调，包含全量快照和新增、移除、修改的实例，实例列表为空时同样会回调
+	// 可以与 SubscribeCallback 同时设置，二者至少设置一个
+	OnEvent func(event model.ServiceEvent)
+	// 按实例 Metadata 过滤回调中的实例，为空时不过滤
+	Selector *model.ExpressionSelector
 }
 
 type SelectAllInstancesParam struct {
@@ -85,10 +117,52 @@ type SelectInstancesParam struct {
 	ServiceName string   `param:"serviceName"`
 	GroupName   string   `param:"groupName"`
 	HealthyOnly bool     `param:"healthyOnly"`
+	// 按实例 Metadata 过滤实例，例如 {Expression: "version=2,zone in (a,b)"}，为空时不过滤
+	Selector *model.ExpressionSelector
+	// 实例过滤条件，设置后忽略 HealthyOnly；为空时 HealthyOnly 为 true 只返回健康实例，为 false 返回全部健康状态的实例
+	Filter *InstanceFilter
+}
+
+// 实例过滤条件，零值表示：任意健康状态、只包含已启用且权重大于 0 的实例、不区分临时和持久实例
+type InstanceFilter struct {
+	// 健康状态：any、healthy、unhealthy，为空时为 any
+	Health string
+	// 是否包含未启用的实例
+	IncludeDisabled bool
+	// 是否包含权重不大于 0 的实例
+	IncludeZeroWeight bool
+	// 实例类型：any、ephemeral、persistent，为空时为 any
+	InstanceType string
+	// 保护阈值（0~1），只选择健康实例且健康实例占比低于该值时，与服务端一样返回全部实例；为 0 时不启用
+	ProtectThreshold float64
 }
 
 type SelectOneHealthInstanceParam struct {
 	Clusters    []string `param:"clusters"`
 	ServiceName string   `param:"serviceName"`
 	GroupName   string   `param:"groupName"`
+	// 本次选择使用的负载均衡实现，为空时使用 ClientConfig.LoadBalancer 指定的实现
+	LoadBalancer loadbalancer.LoadBalancer
+	// 一致性哈希使用的键，例如用户 ID
+	HashKey string
+	// 按实例 Metadata 过滤实例，为空时不过滤
+	Selector *model.ExpressionSelector
+	// 保护阈值（0~1），健康实例占比低于该值时从全部实例中选择；为 0 时不启用
+	ProtectThreshold float64
+	// 就近选择参数，为空时使用 ClientConfig 中的 ClusterName、Zone、ZoneMetadataKey 和 LocalityThreshold
+	Locality *LocalityOption
+}
+
+// 就近选择：依次优先选择同集群、同可用区的实例，该层没有健康实例或健康实例占比低于 Threshold 时退到下一层
+type LocalityOption struct {
+	// 调用方所在的集群，与实例的 ClusterName 比较
+	ClusterName string
+	// 调用方所在的可用区，与实例 Metadata 中 ZoneMetadataKey 对应的值比较
+	Zone string
+	// 实例 Metadata 中表示可用区的键，默认为 zone
+	ZoneMetadataKey string
+	// 健康实例占比（0~1）低于该值时退到下一层，为 0 时只在没有健康实例时退到下一层
+	Threshold float64
+	// 关闭本次调用的就近选择
+	Disable bool
 }